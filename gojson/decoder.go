@@ -0,0 +1,262 @@
+package gojson
+
+import (
+	"bytes"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minRead is the smallest chunk Decoder asks the underlying reader for.
+const minRead = 512
+
+// Decoder reads and decodes gojson values from an input stream. Values
+// already decoded are discarded, so a stream may hold any number of
+// concatenated top-level values of any total size. The text of the value
+// being decoded is buffered whole and parsed in place, so a single value
+// costs about its size on top of its decoded form.
+type Decoder struct {
+	r       io.Reader
+	buf     []byte
+	scanp   int   // start of unread data in buf
+	scanned int64 // amount of data already discarded from buf
+	line    int   // newlines in the discarded data
+	column  int   // bytes of the discarded data after its last newline
+	err     error
+	opts    ParseOptions
+	decOpts DecoderOptions
+	layout  string
+}
+
+// NewDecoder returns a new decoder that reads from r. The decoder introduces
+// its own buffering and may read data from r beyond the gojson values requested.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// SetParseOptions sets options used by DecodeValue and DecodeNodes.
+func (dec *Decoder) SetParseOptions(opts ParseOptions) {
+	dec.opts = opts
+}
+
+// SetDecoderOptions sets options used by Decode, including the TagSink,
+// like in ParseToStructWithOptions.
+func (dec *Decoder) SetDecoderOptions(opts DecoderOptions) {
+	dec.decOpts = opts
+}
+
+// SetTimeLayout sets the layout Decode parses time.Time values with,
+// instead of DefaultTimeLayout.
+func (dec *Decoder) SetTimeLayout(layout string) {
+	dec.layout = layout
+}
+
+// UseNumber makes the decoder return numbers as Number instead of int and
+// float64, and store them as Number into empty interfaces in Decode.
+func (dec *Decoder) UseNumber() {
+	dec.opts.UseNumber = true
+}
+
+// Decode reads the next gojson value from its input and stores it in the
+// value pointed to by v, the same way ParseToStruct does.
+func (dec *Decoder) Decode(v interface{}) error {
+	root, err := dec.decode(ParseOptions{UseNumber: dec.opts.UseNumber})
+	if err != nil {
+		return err
+	}
+	m, arr := splitRoot(root)
+	d := newDecodeState(dec.decOpts)
+	d.timeLayout = dec.layout
+	return parseNodesToStruct(v, m, arr, d)
+}
+
+// DecodeNodes reads the next gojson value from its input and returns it in
+// the same form as ParseAsArrayOrSlice. At the end of the input it returns io.EOF.
+func (dec *Decoder) DecodeNodes() (map[string]Node, []Node, error) {
+	root, err := dec.DecodeValue()
+	if err != nil {
+		return nil, nil, err
+	}
+	m, arr := splitRoot(root)
+	return m, arr, nil
+}
+
+// DecodeValue reads the next gojson value from its input and returns it in
+// the same form as ParseWithOptions. At the end of the input it returns io.EOF.
+func (dec *Decoder) DecodeValue() (interface{}, error) {
+	return dec.decode(dec.opts)
+}
+
+func (dec *Decoder) decode(opts ParseOptions) (interface{}, error) {
+	if err := dec.skipSpace(); err != nil {
+		return nil, err
+	}
+	n, err := dec.readValue()
+	if err != nil {
+		return nil, err
+	}
+	root, _, err := parseBytes(dec.buf[dec.scanp:dec.scanp+n], opts)
+	if err != nil {
+		if serr, ok := err.(*SyntaxError); ok {
+			dec.shiftError(serr, dec.scanp)
+		}
+		return nil, err
+	}
+	dec.scanp += n
+	return root, nil
+}
+
+// More reports whether there is another value in the input stream.
+func (dec *Decoder) More() bool {
+	return dec.skipSpace() == nil
+}
+
+// InputOffset returns the input stream byte offset right after the last
+// decoded value, or where decoding stopped if it has failed.
+func (dec *Decoder) InputOffset() int64 {
+	return dec.scanned + int64(dec.scanp)
+}
+
+// skipSpace advances the decoder to the next non-whitespace byte.
+func (dec *Decoder) skipSpace() error {
+	for {
+		for ; dec.scanp < len(dec.buf); dec.scanp++ {
+			r, _ := utf8.DecodeRune(dec.buf[dec.scanp : dec.scanp+1])
+			if !unicode.IsSpace(r) {
+				return nil
+			}
+		}
+		if dec.err != nil {
+			return dec.err
+		}
+		dec.refill()
+	}
+}
+
+// readValue returns the length of the complete gojson value which starts
+// at dec.scanp, reading more input as needed.
+func (dec *Decoder) readValue() (int, error) {
+	s := valueScanner{}
+	i := 0
+	for {
+		for ; dec.scanp+i < len(dec.buf); i++ {
+			done, expected := s.step(dec.buf[dec.scanp+i])
+			if expected != "" {
+				err := syntaxError(dec.buf[dec.scanp:], i, expected)
+				dec.shiftError(err, dec.scanp)
+				return 0, err
+			}
+			if done {
+				return i + 1, nil
+			}
+		}
+		if dec.err != nil {
+			if dec.err == io.EOF {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, dec.err
+		}
+		dec.refill()
+	}
+}
+
+// shiftError makes position of err, found in data starting at buf[start],
+// relative to the beginning of the stream.
+func (dec *Decoder) shiftError(err *SyntaxError, start int) {
+	line, column := dec.line, dec.column
+	if i := bytes.LastIndexByte(dec.buf[:start], '\n'); i != -1 {
+		line += bytes.Count(dec.buf[:start], []byte{'\n'})
+		column = start - i - 1
+	} else {
+		column += start
+	}
+	err.shift(int(dec.scanned)+start, line+1, column+1)
+}
+
+// refill discards already decoded data and reads more input into buf.
+func (dec *Decoder) refill() {
+	if dec.scanp > 0 {
+		discarded := dec.buf[:dec.scanp]
+		if i := bytes.LastIndexByte(discarded, '\n'); i != -1 {
+			dec.line += bytes.Count(discarded, []byte{'\n'})
+			dec.column = len(discarded) - i - 1
+		} else {
+			dec.column += len(discarded)
+		}
+		dec.scanned += int64(dec.scanp)
+		n := copy(dec.buf, dec.buf[dec.scanp:])
+		dec.buf = dec.buf[:n]
+		dec.scanp = 0
+	}
+	if cap(dec.buf)-len(dec.buf) < minRead {
+		newBuf := make([]byte, len(dec.buf), 2*cap(dec.buf)+minRead)
+		copy(newBuf, dec.buf)
+		dec.buf = newBuf
+	}
+	n, err := dec.r.Read(dec.buf[len(dec.buf):cap(dec.buf)])
+	dec.buf = dec.buf[:len(dec.buf)+n]
+	dec.err = err
+}
+
+// valueScanner finds where a top-level gojson value ends without parsing it.
+type valueScanner struct {
+	started  bool
+	depth    int
+	literal  string
+	inString bool
+	escaped  bool
+	inTag    bool
+}
+
+// step consumes the next byte of a value. It reports whether the value is
+// complete or, if the byte is not acceptable, what was expected instead.
+func (s *valueScanner) step(c byte) (done bool, expected string) {
+	if !s.started {
+		s.started = true
+		switch c {
+		case '{', '[':
+			s.depth = 1
+			return false, ""
+		case 'n':
+			s.literal = "ull"
+			return false, ""
+		}
+		return false, "'{', '[' or null"
+	}
+	if s.literal != "" {
+		if c != s.literal[0] {
+			return false, "null"
+		}
+		s.literal = s.literal[1:]
+		return s.literal == "", ""
+	}
+	switch {
+	case s.inString:
+		if s.escaped {
+			s.escaped = false
+		} else if c == '\\' {
+			s.escaped = true
+		} else if c == '"' {
+			s.inString = false
+		}
+	case s.inTag:
+		if c == '"' {
+			s.inString = true
+		} else if c == '`' {
+			s.inTag = false
+		}
+	default:
+		switch c {
+		case '"':
+			s.inString = true
+		case '`':
+			s.inTag = true
+		case '{', '[':
+			s.depth++
+		case '}', ']':
+			s.depth--
+			return s.depth == 0, ""
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,78 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestConveyDecoder(t *testing.T) {
+	Convey("Decoding a stream of concatenated values", t, func() {
+		stream := `{"name": "John" ` + "`\"editable\": false`" + `, "id": 5}
+			["red", "blue", "green"]
+			null {"name": "Jessy` + "`" + `"}`
+		dec := NewDecoder(iotest.OneByteReader(strings.NewReader(stream)))
+
+		m, arr, err := dec.DecodeNodes()
+		So(err, ShouldBeNil)
+		So(arr, ShouldBeNil)
+		So(m["name"].Value, ShouldEqual, "John")
+		So(m["name"].Tag, ShouldEqual, `"editable": false`)
+		So(dec.InputOffset(), ShouldEqual, strings.Index(stream, "}")+1)
+
+		m, arr, err = dec.DecodeNodes()
+		So(err, ShouldBeNil)
+		So(m, ShouldBeNil)
+		So(len(arr), ShouldEqual, 3)
+
+		m, arr, err = dec.DecodeNodes()
+		So(err, ShouldBeNil)
+		So(m, ShouldBeNil)
+		So(arr, ShouldBeNil)
+
+		So(dec.More(), ShouldBeTrue)
+		m, _, err = dec.DecodeNodes()
+		So(err, ShouldBeNil)
+		So(m["name"].Value, ShouldEqual, "Jessy`")
+		So(dec.InputOffset(), ShouldEqual, len(stream))
+
+		So(dec.More(), ShouldBeFalse)
+		_, _, err = dec.DecodeNodes()
+		So(err, ShouldEqual, io.EOF)
+	})
+
+	Convey("Decoding into a struct", t, func() {
+		type Friend struct {
+			Name string `json:"name"`
+			Id   int
+		}
+		dec := NewDecoder(strings.NewReader(`{"name": "Simone", "Id": 1}{"name": "Victor", "Id": 2}`))
+		friends := []Friend{}
+		for dec.More() {
+			f := Friend{}
+			So(dec.Decode(&f), ShouldBeNil)
+			friends = append(friends, f)
+		}
+		So(len(friends), ShouldEqual, 2)
+		So(friends[1].Name, ShouldEqual, "Victor")
+		So(friends[1].Id, ShouldEqual, 2)
+	})
+
+	Convey("Decoding broken streams", t, func() {
+		Convey("Truncated value should return io.ErrUnexpectedEOF", func() {
+			dec := NewDecoder(strings.NewReader(`{"name": "John"`))
+			_, _, err := dec.DecodeNodes()
+			So(err, ShouldEqual, io.ErrUnexpectedEOF)
+		})
+
+		Convey("Value of unknown type should return error", func() {
+			dec := NewDecoder(strings.NewReader(`{"a": 1} 42`))
+			_, _, err := dec.DecodeNodes()
+			So(err, ShouldBeNil)
+			_, _, err = dec.DecodeNodes()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
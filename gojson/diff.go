@@ -0,0 +1,234 @@
+package gojson
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a difference found by Diff.
+type ChangeKind string
+
+const (
+	ValueAdded   ChangeKind = "add"
+	ValueRemoved ChangeKind = "remove"
+	ValueChanged ChangeKind = "change"
+	TagAdded     ChangeKind = "add-tag"
+	TagRemoved   ChangeKind = "remove-tag"
+	TagChanged   ChangeKind = "change-tag"
+)
+
+// Change is a difference between two gojson trees at the path like
+// "sister.colors[1]", empty for the root. From is the node of the first
+// tree and To the one of the second, the zero Node when there is none.
+// Tags change key by key and TagKey holds the key of a tag change. Tags
+// which can't be parsed are compared whole and their changes have no TagKey.
+type Change struct {
+	Kind   ChangeKind
+	Path   string
+	From   Node
+	To     Node
+	TagKey string
+}
+
+// Diff walks two gojson trees, map[string]Node, *OrderedMap, []Node or
+// Node, and returns their differences. Objects are compared key by key
+// and arrays element by element, so a value added to or removed from the
+// end of an array is a single change. Tags are compared apart from values
+// and key by key: a node which lost `"editable": false` but kept
+// `"unique": true` has a TagRemoved change of the "editable" key, a node
+// with a new value and a new limit is both ValueChanged and TagChanged.
+// Entries which differ only in formatting, like `limit:"10"` and
+// `limit: "10"`, are equal. Numbers are equal if they hold the same number.
+func Diff(a, b interface{}) []Change {
+	return diffNodes(nil, "", rootNode(a), rootNode(b))
+}
+
+func diffNodes(changes []Change, path string, a, b Node) []Change {
+	changes = diffTags(changes, path, a, b)
+	aNodes, aIsObject := objectNodes(a.Value)
+	bNodes, bIsObject := objectNodes(b.Value)
+	if aIsObject && bIsObject {
+		for _, key := range unionKeys(a.Value, b.Value) {
+			aNode, inA := aNodes[key]
+			bNode, inB := bNodes[key]
+			switch {
+			case !inA:
+				changes = append(changes, Change{Kind: ValueAdded, Path: joinPath(path, key), To: bNode})
+			case !inB:
+				changes = append(changes, Change{Kind: ValueRemoved, Path: joinPath(path, key), From: aNode})
+			default:
+				changes = diffNodes(changes, joinPath(path, key), aNode, bNode)
+			}
+		}
+		return changes
+	}
+	aItems, aIsArray := a.Value.([]Node)
+	bItems, bIsArray := b.Value.([]Node)
+	if aIsArray && bIsArray {
+		for i := 0; i < len(aItems) || i < len(bItems); i++ {
+			switch {
+			case i >= len(aItems):
+				changes = append(changes, Change{Kind: ValueAdded, Path: indexPath(path, i), To: bItems[i]})
+			case i >= len(bItems):
+				changes = append(changes, Change{Kind: ValueRemoved, Path: indexPath(path, i), From: aItems[i]})
+			default:
+				changes = diffNodes(changes, indexPath(path, i), aItems[i], bItems[i])
+			}
+		}
+		return changes
+	}
+	if aIsObject || bIsObject || aIsArray || bIsArray || !valuesEqual(a.Value, b.Value) {
+		changes = append(changes, Change{Kind: ValueChanged, Path: path, From: a, To: b})
+	}
+	return changes
+}
+
+// diffTags appends changes of the tag entries of a and b: entries of a in
+// their order, then the entries added to b.
+func diffTags(changes []Change, path string, a, b Node) []Change {
+	if a.Tag == b.Tag {
+		return changes
+	}
+	aTags, aErr := ParseTag(a.Tag)
+	bTags, bErr := ParseTag(b.Tag)
+	if aErr != nil || bErr != nil {
+		c := Change{Kind: TagChanged, Path: path, From: a, To: b}
+		switch {
+		case a.Tag == "":
+			c.Kind = TagAdded
+		case b.Tag == "":
+			c.Kind = TagRemoved
+		}
+		return append(changes, c)
+	}
+	for _, entry := range aTags {
+		c := Change{Path: path, From: a, To: b, TagKey: entry.Key}
+		if other, ok := bTags.Get(entry.Key); !ok {
+			c.Kind = TagRemoved
+		} else if other.String() != entry.String() {
+			c.Kind = TagChanged
+		} else {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	for _, entry := range bTags {
+		if _, ok := aTags.Get(entry.Key); !ok {
+			changes = append(changes, Change{Kind: TagAdded, Path: path, From: a, To: b, TagKey: entry.Key})
+		}
+	}
+	return changes
+}
+
+// sameTags reports whether two tags have the same entries.
+func sameTags(a, b string) bool {
+	aTags, err := ParseTag(a)
+	if err != nil {
+		return false
+	}
+	bTags, err := ParseTag(b)
+	return err == nil && aTags.String() == bTags.String()
+}
+
+// unionKeys returns the keys of both objects: sorted for map[string]Node
+// objects, keys of a *OrderedMap in its order followed by the new keys of
+// the other object.
+func unionKeys(a, b interface{}) []string {
+	keys := objectKeys(a)
+	aNodes, _ := objectNodes(a)
+	for _, key := range objectKeys(b) {
+		if _, ok := aNodes[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	_, aOrdered := a.(*OrderedMap)
+	_, bOrdered := b.(*OrderedMap)
+	if !aOrdered && !bOrdered {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// String returns the change on a single line like
+// `sister.colors[1]: "blue" -> "dark"`.
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "$"
+	}
+	switch c.Kind {
+	case ValueAdded:
+		return path + ": added " + formatNode(c.To)
+	case ValueRemoved:
+		return path + ": removed " + formatNode(c.From)
+	case ValueChanged:
+		return path + ": " + formatNode(Node{Value: c.From.Value}) + " -> " + formatNode(Node{Value: c.To.Value})
+	case TagAdded:
+		return path + ": added tag `" + c.tagEntry(c.To) + "`"
+	case TagRemoved:
+		return path + ": removed tag `" + c.tagEntry(c.From) + "`"
+	}
+	return path + ": tag `" + c.tagEntry(c.From) + "` -> `" + c.tagEntry(c.To) + "`"
+}
+
+// tagEntry returns the entry of the changed key of the node tag, or the
+// whole tag if the change has no key.
+func (c Change) tagEntry(node Node) string {
+	if c.TagKey == "" {
+		return node.Tag
+	}
+	tags, _ := ParseTag(node.Tag)
+	entry, _ := tags.Get(c.TagKey)
+	return entry.String()
+}
+
+// UnifiedDiff renders changes in the unified diff style, a hunk per path
+// headed by it:
+//
+//	@@ sister.colors[1] @@
+//	-"blue"
+//	+"dark"
+//	@@ sister @@
+//	-`"editable": false`
+//	+`"unique": true`
+//
+// Value lines hold gojson values with their tags, tag lines hold the
+// changed tag entries in backquotes.
+func UnifiedDiff(changes []Change) string {
+	var b strings.Builder
+	for i, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "$"
+		}
+		if i == 0 || changes[i-1].Path != c.Path {
+			b.WriteString("@@ " + path + " @@\n")
+		}
+		switch c.Kind {
+		case ValueAdded:
+			b.WriteString("+" + formatNode(c.To) + "\n")
+		case ValueRemoved:
+			b.WriteString("-" + formatNode(c.From) + "\n")
+		case ValueChanged:
+			b.WriteString("-" + formatNode(Node{Value: c.From.Value}) + "\n")
+			b.WriteString("+" + formatNode(Node{Value: c.To.Value}) + "\n")
+		default:
+			if c.Kind != TagAdded {
+				b.WriteString("-`" + c.tagEntry(c.From) + "`\n")
+			}
+			if c.Kind != TagRemoved {
+				b.WriteString("+`" + c.tagEntry(c.To) + "`\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatNode returns the gojson text of a node with its tag on a single line.
+func formatNode(node Node) string {
+	var b strings.Builder
+	if err := serializeNode(&b, node, serializeConfig{Trim: true}, 0); err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return b.String()
+}
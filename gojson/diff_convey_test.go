@@ -0,0 +1,87 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyDiff(t *testing.T) {
+	a, _, _ := ParseAsArrayOrSlice(`{
+		"name": "Joe" ` + "`\"max-length\": 4`" + `,
+		"age": 42,
+		"sister": {"name": "Jessy", "colors": ["red", "blue", "dark"]} ` + "`\"editable\": false`" + `,
+		"pets": null
+	}`)
+	b, _, _ := ParseAsArrayOrSlice(`{
+		"name": "Joe" ` + "`max-length: 4`" + `,
+		"age": 42.0 ` + "`\"number\": < 100`" + `,
+		"sister": {"name": "Jessy", "colors": ["red", "green"]},
+		"friends": [] ` + "`\"unique\": true`" + `,
+		"pets": {"cat": "Tom"}
+	}`)
+
+	Convey("Diff", t, func() {
+		changes := Diff(a, b)
+
+		Convey("Should report value and tag changes with paths", func() {
+			So(changes, ShouldResemble, []Change{
+				{Kind: TagAdded, Path: "age", From: Node{Value: 42}, To: Node{Value: 42.0, Tag: `"number": < 100`}, TagKey: "number"},
+				{Kind: ValueAdded, Path: "friends", To: Node{Value: []Node{}, Tag: `"unique": true`}},
+				{Kind: ValueChanged, Path: "pets", To: Node{Value: map[string]Node{"cat": {Value: "Tom"}}}},
+				{Kind: TagRemoved, Path: "sister", From: a["sister"], To: b["sister"], TagKey: "editable"},
+				{Kind: ValueChanged, Path: "sister.colors[1]", From: Node{Value: "blue"}, To: Node{Value: "green"}},
+				{Kind: ValueRemoved, Path: "sister.colors[2]", From: Node{Value: "dark"}},
+			})
+			So(Diff(a, a), ShouldBeEmpty)
+			So(Diff([]Node{{Value: 1, Tag: "a:1"}}, []Node{{Value: 1, Tag: "a:2"}})[0].Kind, ShouldEqual, TagChanged)
+		})
+
+		Convey("Should compare tags key by key", func() {
+			x := Node{Value: "Jessy", Tag: `"editable": false, "unique": true, "max-length": 10`}
+			y := Node{Value: "Jessy", Tag: `"unique": true, "max-length": 20, "required": true`}
+			changes := Diff(x, y)
+			kinds := map[string]ChangeKind{}
+			for _, c := range changes {
+				kinds[c.TagKey] = c.Kind
+			}
+			So(kinds, ShouldResemble, map[string]ChangeKind{
+				"editable": TagRemoved, "max-length": TagChanged, "required": TagAdded,
+			})
+			So(changes[0].String(), ShouldEqual, "$: removed tag `\"editable\": false`")
+			So(changes[1].String(), ShouldEqual, "$: tag `\"max-length\": 10` -> `\"max-length\": 20`")
+			So(UnifiedDiff(changes), ShouldEqual, "@@ $ @@\n"+
+				"-`\"editable\": false`\n"+
+				"-`\"max-length\": 10`\n+`\"max-length\": 20`\n"+
+				"+`\"required\": true`\n")
+		})
+
+		Convey("Should keep the key order of ordered maps", func() {
+			x, _ := ParseWithOptions(`{"b": 1, "a": 1}`, ParseOptions{OrderedMaps: true})
+			y, _ := ParseWithOptions(`{"b": 2, "a": 2, "c": 2}`, ParseOptions{OrderedMaps: true})
+			paths := []string{}
+			for _, c := range Diff(x, y) {
+				paths = append(paths, c.Path)
+			}
+			So(paths, ShouldResemble, []string{"b", "a", "c"})
+		})
+
+		Convey("Should render changes", func() {
+			So(changes[0].String(), ShouldEqual, "age: added tag `\"number\": < 100`")
+			So(changes[1].String(), ShouldEqual, "friends: added []`\"unique\": true`")
+			So(changes[4].String(), ShouldEqual, `sister.colors[1]: "blue" -> "green"`)
+			So(Change{Kind: TagChanged, From: Node{Tag: "a:1"}, To: Node{Tag: "a:2"}}.String(), ShouldEqual, "$: tag `a:1` -> `a:2`")
+
+			So(UnifiedDiff(changes[2:]), ShouldEqual, `@@ pets @@
+-null
++{"cat":"Tom"}
+@@ sister @@
+-`+"`\"editable\": false`"+`
+@@ sister.colors[1] @@
+-"blue"
++"green"
+@@ sister.colors[2] @@
+-"dark"
+`)
+		})
+	})
+}
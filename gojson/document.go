@@ -0,0 +1,247 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Document is an editable gojson tree. Its methods take paths like
+// "sister.colors[1]", the same ones Get accepts, and change the tree in
+// place. Nodes which aren't edited keep their values and tags.
+type Document struct {
+	root Node
+}
+
+// NewDocument returns a document around root, which is map[string]Node,
+// *OrderedMap, []Node or Node. Nil root is an empty object.
+func NewDocument(root interface{}) *Document {
+	if root == nil {
+		root = map[string]Node{}
+	}
+	return &Document{root: rootNode(root)}
+}
+
+// ParseDocument parses gojson into a document. Objects keep the order of
+// their keys, so do the serialized edits.
+func ParseDocument(gojson string) (*Document, error) {
+	root, err := ParseWithOptions(gojson, ParseOptions{OrderedMaps: true})
+	if err != nil {
+		return nil, err
+	}
+	return NewDocument(root), nil
+}
+
+// Root returns the root value of the document.
+func (d *Document) Root() interface{} {
+	return d.root.Value
+}
+
+// Serialize returns the gojson text of the document like Serialize does.
+func (d *Document) Serialize(trim bool) (string, error) {
+	return Serialize(d.root.Value, trim)
+}
+
+// Get returns the node at the path.
+func (d *Document) Get(path string) (Node, error) {
+	return Get(d.root, path)
+}
+
+// Query returns the nodes selected by a JSONPath expression, see Query.
+func (d *Document) Query(query string) ([]Match, error) {
+	return Query(d.root, query)
+}
+
+// Set stores value at the path. Missing objects on the way, as well as
+// the last key, are created and null values on the way become objects.
+// A Node value is stored with its tag, any other value is converted the
+// way Marshal does and, unless it has a tag of its own, keeps the tag of
+// the node it replaces.
+func (d *Document) Set(path string, value interface{}) error {
+	node, isNode := value.(Node)
+	if !isNode {
+		var err error
+		if node, err = getNode(value, reflect.ValueOf(value)); err != nil {
+			return err
+		}
+	}
+	return d.editParent(path, true, func(parent Node, last pathSegment) (Node, error) {
+		if old, ok := last.child(parent); ok && !isNode && node.Tag == "" {
+			node.Tag = old.Tag
+		}
+		return setChild(parent, last, node)
+	})
+}
+
+// SetTag replaces the tag of the node at the path, empty tag removes it.
+func (d *Document) SetTag(path string, tag string) error {
+	return d.edit(path, func(node Node) (Node, error) {
+		node.Tag = tag
+		return node, nil
+	})
+}
+
+// Delete removes the key of an object or the element of an array at the
+// path. Following elements of the array are shifted.
+func (d *Document) Delete(path string) error {
+	return d.editParent(path, false, func(parent Node, last pathSegment) (Node, error) {
+		if _, ok := last.child(parent); !ok {
+			return parent, ErrNotFound
+		}
+		if last.kind == segmentKey {
+			switch v := parent.Value.(type) {
+			case map[string]Node:
+				delete(v, last.key)
+			case *OrderedMap:
+				v.Delete(last.key)
+			}
+			return parent, nil
+		}
+		items := parent.Value.([]Node)
+		i := arrayIndex(last.index, len(items))
+		parent.Value = append(append([]Node{}, items[:i]...), items[i+1:]...)
+		return parent, nil
+	})
+}
+
+// Insert puts value into the array at the path before the element at the
+// index. Index equal to the length of the array appends value. The value
+// is converted the way Set does.
+func (d *Document) Insert(path string, index int, value interface{}) error {
+	node, isNode := value.(Node)
+	if !isNode {
+		var err error
+		if node, err = getNode(value, reflect.ValueOf(value)); err != nil {
+			return err
+		}
+	}
+	return d.edit(path, func(array Node) (Node, error) {
+		items, ok := array.Value.([]Node)
+		if !ok {
+			return array, fmt.Errorf("gojson: %q is not an array", path)
+		}
+		if index < 0 || index > len(items) {
+			return array, fmt.Errorf("gojson: index %d is out of range of %q", index, path)
+		}
+		result := make([]Node, 0, len(items)+1)
+		result = append(result, items[:index]...)
+		result = append(result, node)
+		array.Value = append(result, items[index:]...)
+		return array, nil
+	})
+}
+
+// edit replaces the node at the path with the result of fn.
+func (d *Document) edit(path string, fn func(Node) (Node, error)) error {
+	segments, err := parseNodePath(path)
+	if err != nil {
+		return err
+	}
+	root, err := editNode(d.root, segments, false, fn)
+	if err != nil {
+		return pathError(path, err)
+	}
+	d.root = root
+	return nil
+}
+
+// editParent replaces the parent of the node at the path with the result
+// of fn, which also receives the last segment of the path.
+func (d *Document) editParent(path string, create bool, fn func(Node, pathSegment) (Node, error)) error {
+	segments, err := parseNodePath(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("gojson: path %q should not point to the root", path)
+	}
+	last := segments[len(segments)-1]
+	root, err := editNode(d.root, segments[:len(segments)-1], create, func(parent Node) (Node, error) {
+		return fn(parent, last)
+	})
+	if err != nil {
+		return pathError(path, err)
+	}
+	d.root = root
+	return nil
+}
+
+// pathError adds the path to errors of a value on the way to the node.
+func pathError(path string, err error) error {
+	switch err {
+	case errNotObject:
+		return fmt.Errorf("gojson: a key of %q is not in an object", path)
+	case errNotArray:
+		return fmt.Errorf("gojson: an index of %q is not in an array", path)
+	}
+	return err
+}
+
+var (
+	errNotObject = errors.New("gojson: not an object")
+	errNotArray  = errors.New("gojson: not an array")
+)
+
+// editNode returns node with the node at segments replaced by the result
+// of fn. With create, missing keys on the way are added as empty objects.
+func editNode(node Node, segments []pathSegment, create bool, fn func(Node) (Node, error)) (Node, error) {
+	if len(segments) == 0 {
+		return fn(node)
+	}
+	segment := segments[0]
+	child, ok := segment.child(node)
+	if !ok {
+		if !create || segment.kind != segmentKey {
+			return node, ErrNotFound
+		}
+		child = Node{Value: map[string]Node{}}
+		if _, ordered := node.Value.(*OrderedMap); ordered {
+			child.Value = NewOrderedMap()
+		}
+	} else if create && child.Value == nil {
+		child.Value = map[string]Node{}
+	}
+	child, err := editNode(child, segments[1:], create, fn)
+	if err != nil {
+		return node, err
+	}
+	return setChild(node, segment, child)
+}
+
+// setChild stores child under the key or the index of the segment. Null
+// node becomes an object holding the key.
+func setChild(node Node, segment pathSegment, child Node) (Node, error) {
+	if segment.kind == segmentIndex {
+		items, ok := node.Value.([]Node)
+		if !ok {
+			return node, errNotArray
+		}
+		i := arrayIndex(segment.index, len(items))
+		if i < 0 || i >= len(items) {
+			return node, ErrNotFound
+		}
+		items[i] = child
+		return node, nil
+	}
+	if node.Value == nil {
+		node.Value = map[string]Node{}
+	}
+	switch v := node.Value.(type) {
+	case map[string]Node:
+		v[segment.key] = child
+	case *OrderedMap:
+		v.Set(segment.key, child)
+	default:
+		return node, errNotObject
+	}
+	return node, nil
+}
+
+// arrayIndex returns the index of an array of length n, negative index
+// counts from the end.
+func arrayIndex(i, n int) int {
+	if i < 0 {
+		return i + n
+	}
+	return i
+}
@@ -0,0 +1,79 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyDocument(t *testing.T) {
+	src := `{"name": "Joe" ` + "`\"max-length\": 4`" + `, "sister": {"name": "Jessy", "colors": ["red", "blue"] ` +
+		"`\"editable\": false`" + `}, "nick": null}`
+
+	Convey("Document", t, func() {
+		doc, err := ParseDocument(src)
+		So(err, ShouldBeNil)
+
+		Convey("Set should replace values keeping tags and key order", func() {
+			So(doc.Set("name", "Jim"), ShouldBeNil)
+			So(doc.Set("sister.colors[-1]", "dark"), ShouldBeNil)
+			So(doc.Set("sister.name", Node{Value: "Jane", Tag: `"unique": true`}), ShouldBeNil)
+			re, err := doc.Serialize(true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"name":"Jim"`+"`\"max-length\": 4`"+`,"sister":{"name":"Jane"`+
+				"`\"unique\": true`"+`,"colors":["red","dark"]`+"`\"editable\": false`"+`},"nick":null}`)
+		})
+
+		Convey("Set should create intermediate objects", func() {
+			So(doc.Set("address.city.name", "Kyiv"), ShouldBeNil)
+			So(doc.Set("nick.short", "J"), ShouldBeNil)
+			So(doc.Set(`sister["best friend"]`, map[string]int{"age": 17}), ShouldBeNil)
+			node, err := doc.Get("address.city.name")
+			So(err, ShouldBeNil)
+			So(node.Value, ShouldEqual, "Kyiv")
+			node, _ = doc.Get("nick.short")
+			So(node.Value, ShouldEqual, "J")
+			node, _ = doc.Get(`sister["best friend"].age`)
+			So(node.Value, ShouldEqual, 17)
+
+			So(doc.Set("sister.colors[5]", "x"), ShouldEqual, ErrNotFound)
+			So(doc.Set("name.first", "x").Error(), ShouldEqual, `gojson: a key of "name.first" is not in an object`)
+			So(doc.Set("", "x"), ShouldNotBeNil)
+		})
+
+		Convey("SetTag should change only the tag", func() {
+			So(doc.SetTag("sister.colors", ""), ShouldBeNil)
+			So(doc.SetTag("nick", `"required": true`), ShouldBeNil)
+			So(doc.SetTag("missing", `"required": true`), ShouldEqual, ErrNotFound)
+			node, _ := doc.Get("sister.colors")
+			So(node, ShouldResemble, Node{Value: []Node{{Value: "red"}, {Value: "blue"}}})
+			node, _ = doc.Get("nick")
+			So(node, ShouldResemble, Node{Tag: `"required": true`})
+		})
+
+		Convey("Delete and Insert should edit objects and arrays", func() {
+			So(doc.Insert("sister.colors", 1, "green"), ShouldBeNil)
+			So(doc.Insert("sister.colors", 3, Node{Value: "dark", Tag: `"unique": true`}), ShouldBeNil)
+			So(doc.Delete("sister.colors[0]"), ShouldBeNil)
+			So(doc.Delete("nick"), ShouldBeNil)
+			So(doc.Delete("nick"), ShouldEqual, ErrNotFound)
+			So(doc.Insert("sister.colors", 9, "x"), ShouldNotBeNil)
+			So(doc.Insert("sister", 0, "x"), ShouldNotBeNil)
+
+			re, _ := doc.Serialize(true)
+			So(re, ShouldEqual, `{"name":"Joe"`+"`\"max-length\": 4`"+`,"sister":{"name":"Jessy","colors":`+
+				`["green","blue","dark"`+"`\"unique\": true`"+`]`+"`\"editable\": false`"+`}}`)
+		})
+
+		Convey("Should work on plain maps and slices", func() {
+			arr := []Node{{Value: 1}}
+			doc := NewDocument(arr)
+			So(doc.Insert("", 0, 0), ShouldBeNil)
+			So(doc.Set("[1]", 2), ShouldBeNil)
+			So(doc.Root(), ShouldResemble, []Node{{Value: 0}, {Value: 2}})
+
+			doc = NewDocument(nil)
+			So(doc.Set("a.b", true), ShouldBeNil)
+			So(doc.Root(), ShouldResemble, map[string]Node{"a": {Value: map[string]Node{"b": {Value: true}}}})
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package gojson
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+)
+
+// Encoder writes gojson values to an output stream.
+type Encoder struct {
+	w      io.Writer
+	buf    bytes.Buffer
+	config serializeConfig
+}
+
+// NewEncoder returns a new encoder that writes to w. By default values
+// are written without any whitespacing, as Serialize does with trim set.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:      w,
+		config: serializeConfig{Trim: true},
+	}
+}
+
+// SetIndent instructs the encoder to format each subsequent value so that
+// every nested element begins on a new line starting with prefix followed
+// by one or more copies of indent according to the nesting depth.
+// Calling SetIndent("", "") disables indentation.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.config.Prefix = prefix
+	enc.config.Indent = indent
+	enc.config.Trim = prefix == "" && indent == ""
+}
+
+// SetSortKeys makes the encoder write keys of every *OrderedMap sorted
+// instead of in their order. Keys of map[string]Node are always sorted.
+func (enc *Encoder) SetSortKeys(sort bool) {
+	enc.config.SortKeys = sort
+}
+
+// SetTimeLayout sets the layout time.Time values are written with, instead
+// of DefaultTimeLayout. The "format" key of the tag of a value takes
+// precedence.
+func (enc *Encoder) SetTimeLayout(layout string) {
+	enc.config.TimeLayout = layout
+}
+
+// Encode writes the gojson encoding of v to the stream, followed by a
+// newline character. v may be map[string]Node, *OrderedMap, []Node or
+// any value accepted by SerializeStruct. The value is encoded as a whole
+// before it is written, so nothing reaches the stream when it fails.
+func (enc *Encoder) Encode(v interface{}) error {
+	switch v.(type) {
+	case map[string]Node, *OrderedMap, []Node:
+	default:
+		node, err := getNode(v, reflect.ValueOf(v))
+		if err != nil {
+			return err
+		}
+		v = node.Value
+	}
+	enc.buf.Reset()
+	if err := serialize(&enc.buf, v, enc.config); err != nil {
+		return err
+	}
+	enc.buf.WriteByte('\n')
+	_, err := enc.w.Write(enc.buf.Bytes())
+	return err
+}
@@ -0,0 +1,87 @@
+package gojson
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestConveyEncoder(t *testing.T) {
+	Convey("Encoding to a stream", t, func() {
+		colors := Node{
+			Value: []Node{
+				Node{Value: "red"},
+				Node{Value: "blue", Tag: `"editable": false`},
+			},
+			Tag: `"list": ["red", "blue", "green"]`,
+		}
+		m := map[string]Node{
+			"colors": colors,
+		}
+
+		Convey("Should write values without whitespacing by default", func() {
+			var b bytes.Buffer
+			enc := NewEncoder(&b)
+			So(enc.Encode(m), ShouldBeNil)
+			So(enc.Encode([]Node{Node{Value: 5}}), ShouldBeNil)
+			So(b.String(), ShouldEqual, `{"colors":["red","blue"`+"`\"editable\": false`"+`]`+
+				"`\"list\": [\"red\", \"blue\", \"green\"]`"+"}\n[5]\n")
+		})
+
+		Convey("Should use prefix and indent set by SetIndent", func() {
+			var b bytes.Buffer
+			enc := NewEncoder(&b)
+			enc.SetIndent(">", "\t")
+			So(enc.Encode(m), ShouldBeNil)
+			So(b.String(), ShouldEqual, "{\n>\t\"colors\": [\n>\t\t\"red\",\n>\t\t\"blue\" "+
+				"`\"editable\": false`\n>\t] `\"list\": [\"red\", \"blue\", \"green\"]`\n>}\n")
+		})
+
+		Convey("Should encode structs like SerializeStruct", func() {
+			type Friend struct {
+				Name string `json:"name" limit:"10"`
+			}
+			var b bytes.Buffer
+			So(NewEncoder(&b).Encode([]Friend{Friend{Name: "Simone"}}), ShouldBeNil)
+			So(b.String(), ShouldEqual, `[{"name":"Simone"`+"`limit:\"10\"`"+"}]\n")
+		})
+
+		Convey("Should be readable by Decoder", func() {
+			var b bytes.Buffer
+			enc := NewEncoder(&b)
+			enc.SetIndent("", "  ")
+			So(enc.Encode(m), ShouldBeNil)
+			So(enc.Encode(m), ShouldBeNil)
+			dec := NewDecoder(&b)
+			for i := 0; i < 2; i++ {
+				r, _, err := dec.DecodeNodes()
+				So(err, ShouldBeNil)
+				So(r["colors"].Tag, ShouldEqual, colors.Tag)
+			}
+		})
+
+		Convey("Should return error on wrong input type", func() {
+			var b bytes.Buffer
+			So(NewEncoder(&b).Encode(5), ShouldNotBeNil)
+			So(b.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Should write nothing when a large value fails late", func() {
+			var b bytes.Buffer
+			value := struct {
+				Items []string
+				Ratio float64
+			}{Ratio: math.NaN()}
+			for i := 0; i < 1000; i++ {
+				value.Items = append(value.Items, "item")
+			}
+			So(NewEncoder(&b).Encode(value), ShouldNotBeNil)
+			So(b.Len(), ShouldEqual, 0)
+			tail := map[string]Node{"a": {Value: strings.Repeat("x", 5000)}, "b": {Value: Number("1x")}}
+			So(NewEncoder(&b).Encode(tail), ShouldNotBeNil)
+			So(b.Len(), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package gojson
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetRadius is how many bytes around the offending character
+// SyntaxError keeps in its snippet.
+const snippetRadius = 40
+
+// SyntaxError describes where and why gojson input could not be parsed.
+type SyntaxError struct {
+	Offset   int    // byte offset of the offending character
+	Line     int    // 1-based line of the offending character
+	Column   int    // 1-based byte column of the offending character
+	Expected string // class of token the parser was waiting for
+	Snippet  string // offending line with a caret under the offending character
+
+	found string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("gojson: syntax error at line %d, column %d: unexpected %s, expected %s",
+		e.Line, e.Column, e.found, e.Expected)
+}
+
+// syntaxError returns a SyntaxError for the character of str at offset c,
+// which may also point right past the end of str.
+func syntaxError(str []byte, c int, expected string) *SyntaxError {
+	e := &SyntaxError{
+		Offset:   c,
+		Expected: expected,
+		found:    "end of input",
+	}
+	if c < len(str) {
+		r, _ := utf8.DecodeRune(str[c:])
+		e.found = fmt.Sprintf("%q", r)
+	}
+	lineStart := bytes.LastIndexByte(str[:c], '\n') + 1
+	lineEnd := len(str)
+	if i := bytes.IndexByte(str[c:], '\n'); i != -1 {
+		lineEnd = c + i
+	}
+	e.Line = bytes.Count(str[:lineStart], []byte{'\n'}) + 1
+	e.Column = c - lineStart + 1
+	e.Snippet = snippet(str[lineStart:lineEnd], c-lineStart)
+	return e
+}
+
+// shift moves the error position by the position of the beginning of the
+// parsed input inside of some bigger one.
+func (e *SyntaxError) shift(offset, line, column int) {
+	e.Offset += offset
+	if e.Line == 1 {
+		e.Column += column - 1
+	}
+	e.Line += line - 1
+}
+
+// snippet returns line with a caret under the character at offset c.
+func snippet(line []byte, c int) string {
+	start, end := 0, len(line)
+	if c > snippetRadius {
+		start = c - snippetRadius
+	}
+	if end-c > snippetRadius {
+		end = c + snippetRadius
+	}
+	// keep the window on rune boundaries
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+	text := strings.TrimRight(string(line[start:end]), "\r")
+	caret := []byte{}
+	for _, r := range string(line[start:c]) {
+		if r == '\t' {
+			caret = append(caret, '\t')
+		} else {
+			caret = append(caret, ' ')
+		}
+	}
+	return text + "\n" + string(caret) + "^"
+}
+
+// UnmarshalTypeError describes a gojson value which can't be stored into
+// a Go value of some type.
+type UnmarshalTypeError struct {
+	Path       string       // path of the value like "sister.colors[1]", empty for the root
+	GoType     reflect.Type // type of the Go value the gojson value was decoded into
+	GoJSONType string       // "object", "array", "string", "number", "bool" or "null"
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	msg := "gojson: cannot unmarshal " + e.GoJSONType + " into Go value of type " + e.GoType.String()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
+
+// UnsupportedTypeError is returned when a Go value of some type can't be
+// represented in gojson, like a channel, a function or a map which keys
+// are not strings.
+type UnsupportedTypeError struct {
+	GoType reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "gojson: unsupported type: " + e.GoType.String()
+}
+
+// UnsupportedValueError is returned when a Go value can't be represented
+// in gojson, like a NaN or an infinite float.
+type UnsupportedValueError struct {
+	Value reflect.Value
+	Str   string
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return "gojson: unsupported value: " + e.Str
+}
+
+// FieldsError lists every object key without a matching struct field and
+// every struct field without a matching key found by a strict decoding,
+// see DecoderOptions.
+type FieldsError struct {
+	Unknown []string // paths of the unknown keys like "sister.age"
+	Missing []string // paths of the missing keys like "sister.colors"
+}
+
+func (e *FieldsError) Error() string {
+	parts := []string{}
+	if len(e.Unknown) != 0 {
+		parts = append(parts, "unknown fields "+strings.Join(e.Unknown, ", "))
+	}
+	if len(e.Missing) != 0 {
+		parts = append(parts, "missing fields "+strings.Join(e.Missing, ", "))
+	}
+	return "gojson: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,192 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConveySyntaxError(t *testing.T) {
+	Convey("Reporting syntax errors", t, func() {
+		Convey("Should point to line and column of the offending character", func() {
+			_, _, err := ParseAsArrayOrSlice("{\n\t\"name\": \"John\",\n\t\"isActive\": true\n\t\"id\": 1\n}")
+			serr, ok := err.(*SyntaxError)
+			So(ok, ShouldBeTrue)
+			So(serr.Offset, ShouldEqual, 38)
+			So(serr.Line, ShouldEqual, 4)
+			So(serr.Column, ShouldEqual, 2)
+			So(serr.Expected, ShouldEqual, "',' or '}'")
+			So(serr.Snippet, ShouldEqual, "\t\"id\": 1\n\t^")
+			So(serr.Error(), ShouldEqual, `gojson: syntax error at line 4, column 2: unexpected '"', expected ',' or '}'`)
+		})
+
+		Convey("Should be returned for every kind of broken input", func() {
+			inputs := map[string]string{
+				``:                  "'{', '[' or null",
+				`5`:                 "'{', '[' or null",
+				`{"a": }`:           "value",
+				`{"a": 1,}`:         "object key",
+				`{"a" 1}`:           "':'",
+				`{"a": 1`:           "'}'",
+				`["a" "b"]`:         "',' or ']'",
+				`["a",]`:            "value",
+				`{"a": ["b"`:        "']'",
+				"{`tag`}":           "object key",
+				"[\"a\", \"b\" 1]":  "',' or ']'",
+				`{"a": {"b": 1 2}}`: "',' or '}'",
+				`{"a":: 1}`:         "value",
+				`{"a" : : 1}`:       "value",
+				`{: 1}`:             "object key",
+				`{"a": 1 :}`:        "',' or '}'",
+				`[: 1]`:             "value",
+				`["a": 1]`:          "',' or ']'",
+			}
+			for input, expected := range inputs {
+				_, _, err := ParseAsArrayOrSlice(input)
+				serr, ok := err.(*SyntaxError)
+				So(ok, ShouldBeTrue)
+				So(serr.Expected, ShouldEqual, expected)
+			}
+		})
+
+		Convey("Should cut long lines in the snippet", func() {
+			long := `{"a": "` + strings.Repeat("x", 100) + `" "b": 1}`
+			_, _, err := ParseAsArrayOrSlice(long)
+			serr := err.(*SyntaxError)
+			So(serr.Column, ShouldEqual, 110)
+			So(serr.Snippet, ShouldEqual, strings.Repeat("x", 38)+`" "b": 1}`+"\n"+strings.Repeat(" ", 40)+"^")
+		})
+
+		Convey("Should be relative to the stream in Decoder", func() {
+			dec := NewDecoder(strings.NewReader("{\"a\": 1}\n  {\"b\": 2,\n \"c\" 3}"))
+			_, _, err := dec.DecodeNodes()
+			So(err, ShouldBeNil)
+			_, _, err = dec.DecodeNodes()
+			serr := err.(*SyntaxError)
+			So(serr.Offset, ShouldEqual, 25)
+			So(serr.Line, ShouldEqual, 3)
+			So(serr.Column, ShouldEqual, 6)
+
+			dec = NewDecoder(strings.NewReader("{\"a\": 1}  {\"b\" 2}"))
+			dec.DecodeNodes()
+			_, _, err = dec.DecodeNodes()
+			serr = err.(*SyntaxError)
+			So(serr.Line, ShouldEqual, 1)
+			So(serr.Column, ShouldEqual, 16)
+		})
+
+		Convey("Should be returned by ParseTag", func() {
+			_, err := ParseTag(`"number": < 400, "list": ["red" "blue"]`)
+			serr, ok := err.(*SyntaxError)
+			So(ok, ShouldBeTrue)
+			So(serr.Column, ShouldEqual, 33)
+			So(serr.Expected, ShouldEqual, "',' or ']'")
+		})
+	})
+}
+
+func TestConveyTypeErrors(t *testing.T) {
+	type sister struct {
+		Name   string `json:"name"`
+		Colors []int  `json:"colors"`
+	}
+	type person struct {
+		Sister sister         `json:"sister"`
+		Pets   map[string]int `json:"pets"`
+		Ch     chan int       `json:"ch"`
+	}
+
+	Convey("Reflective failures should be returned as typed errors", t, func() {
+		Convey("ParseToStruct should return UnmarshalTypeError with the path of the value", func() {
+			p := person{}
+			err := ParseToStruct(&p, `{"sister": {"name": "Jessy", "colors": [1, "blue"]}}`)
+			terr, ok := err.(*UnmarshalTypeError)
+			So(ok, ShouldBeTrue)
+			So(terr.Path, ShouldEqual, "sister.colors[1]")
+			So(terr.GoType, ShouldEqual, reflect.TypeOf(0))
+			So(terr.GoJSONType, ShouldEqual, "string")
+			So(err.Error(), ShouldEqual, "gojson: cannot unmarshal string into Go value of type int at sister.colors[1]")
+
+			err = ParseToStruct(&p, `{"pets": {"a b": [1]}}`)
+			So(err.(*UnmarshalTypeError).Path, ShouldEqual, `pets["a b"]`)
+			So(err.(*UnmarshalTypeError).GoJSONType, ShouldEqual, "array")
+		})
+
+		Convey("Unmarshal should return UnmarshalTypeError", func() {
+			var m map[bool]string
+			err := Unmarshal([]byte(`{"1": "a"}`), &m)
+			So(err, ShouldResemble, &UnmarshalTypeError{GoType: reflect.TypeOf(m), GoJSONType: "object"})
+		})
+
+		Convey("SerializeStruct should return UnsupportedTypeError", func() {
+			_, err := SerializeStruct(person{Ch: make(chan int)}, true)
+			So(err, ShouldResemble, &UnsupportedTypeError{GoType: reflect.TypeOf(make(chan int))})
+			_, err = SerializeStruct(map[bool]int{true: 1}, true)
+			So(err.Error(), ShouldEqual, "gojson: unsupported type: map[bool]int")
+		})
+
+		Convey("Cyclic values should be UnsupportedValueError", func() {
+			type ring struct {
+				Name string
+				Self *ring
+			}
+			r := &ring{Name: "r"}
+			r.Self = r
+			_, err := Marshal(r)
+			So(err.Error(), ShouldEqual, "gojson: unsupported value: encountered a cycle via *gojson.ring")
+			_, err = SerializeStruct(r, true)
+			_, ok := err.(*UnsupportedValueError)
+			So(ok, ShouldBeTrue)
+
+			m := map[string]interface{}{}
+			m["m"] = m
+			_, err = Marshal(m)
+			So(err, ShouldNotBeNil)
+			s := []interface{}{nil}
+			s[0] = s
+			_, err = Marshal(s)
+			So(err, ShouldNotBeNil)
+
+			shared := &ring{Name: "shared"}
+			data, err := Marshal([]*ring{shared, shared})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `[{"Name":"shared","Self":null},{"Name":"shared","Self":null}]`)
+		})
+
+		Convey("Go values held by nodes should be encoded or rejected", func() {
+			re, err := Serialize(map[string]Node{"a": {Value: []string{"x"}, Tag: "limit:1"}}, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"a":["x"]`+"`limit:1`"+`}`)
+			_, err = Serialize(map[string]Node{"a": {Value: make(chan int)}}, true)
+			So(err, ShouldResemble, &UnsupportedTypeError{GoType: reflect.TypeOf(make(chan int))})
+			_, err = Marshal([]Node{{Value: func() {}}})
+			So(err, ShouldHaveSameTypeAs, &UnsupportedTypeError{})
+		})
+
+		Convey("Non-finite floats should be UnsupportedValueError", func() {
+			_, err := Serialize(map[string]Node{"a": {Value: math.NaN()}}, true)
+			So(err.Error(), ShouldEqual, "gojson: unsupported value: NaN")
+			_, err = Marshal(math.Inf(1))
+			So(err.Error(), ShouldEqual, "gojson: unsupported value: +Inf")
+			_, err = Marshal(struct{ F float32 }{F: float32(math.Inf(-1))})
+			_, ok := err.(*UnsupportedValueError)
+			So(ok, ShouldBeTrue)
+			_, err = Marshal(struct {
+				F float64 `json:",string"`
+			}{F: math.NaN()})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Malformed ,string values should be UnmarshalTypeError with path", func() {
+			var v struct {
+				Z int `json:"z,string"`
+			}
+			err := ParseToStruct(&v, `{"z": "abc"}`)
+			So(err, ShouldResemble, &UnmarshalTypeError{Path: "z", GoType: reflect.TypeOf(0), GoJSONType: "string"})
+			err = Unmarshal([]byte(`{"z": "1 2"}`), &v)
+			So(err, ShouldResemble, &UnmarshalTypeError{Path: "z", GoType: reflect.TypeOf(0), GoJSONType: "string"})
+		})
+	})
+}
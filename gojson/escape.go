@@ -0,0 +1,147 @@
+package gojson
+
+import (
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// scanString decodes the string literal which starts with the quote at
+// str[c]. It returns the decoded bytes, which are never nil, and the
+// offset of the closing quote.
+func scanString(str []byte, c int) ([]byte, int, error) {
+	result := []byte{}
+	for i := c + 1; i < len(str); i++ {
+		switch b := str[i]; {
+		case b == '"':
+			return result, i, nil
+		case b == '\\':
+			r, size, ok := decodeEscape(str[i:])
+			if !ok {
+				return nil, i, syntaxError(str, i, "escape sequence")
+			}
+			result = utf8.AppendRune(result, r)
+			i += size - 1
+		case b < ' ':
+			return nil, i, syntaxError(str, i, "escaped control character")
+		default:
+			result = append(result, b)
+		}
+	}
+	return nil, len(str), syntaxError(str, len(str), "'\"'")
+}
+
+// decodeEscape decodes the escape sequence at the beginning of s. UTF-16
+// surrogate pairs are combined, a lone surrogate becomes U+FFFD.
+func decodeEscape(s []byte) (rune, int, bool) {
+	if len(s) < 2 {
+		return 0, 0, false
+	}
+	switch s[1] {
+	case '"', '\\', '/':
+		return rune(s[1]), 2, true
+	case 'b':
+		return '\b', 2, true
+	case 'f':
+		return '\f', 2, true
+	case 'n':
+		return '\n', 2, true
+	case 'r':
+		return '\r', 2, true
+	case 't':
+		return '\t', 2, true
+	case 'u':
+		r, ok := decodeHex(s[2:])
+		if !ok {
+			return 0, 0, false
+		}
+		if !utf16.IsSurrogate(r) {
+			return r, 6, true
+		}
+		if len(s) >= 12 && s[6] == '\\' && s[7] == 'u' {
+			if r2, ok := decodeHex(s[8:]); ok {
+				if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+					return dec, 12, true
+				}
+			}
+		}
+		return utf8.RuneError, 6, true
+	}
+	return 0, 0, false
+}
+
+func decodeHex(s []byte) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(string(s[:4]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(v), true
+}
+
+// scanTag returns the contents of the tag which starts with the backtick
+// at str[c] and the offset of the closing backtick. Backticks inside of
+// string literals of the tag don't close it.
+func scanTag(str []byte, c int) ([]byte, int, error) {
+	inString, escaped := false, false
+	for i := c + 1; i < len(str); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && str[i] == '\\':
+			escaped = true
+		case str[i] == '"':
+			inString = !inString
+		case str[i] == '`' && !inString:
+			return str[c+1 : i], i, nil
+		}
+	}
+	return nil, len(str), syntaxError(str, len(str), "'`'")
+}
+
+// quoteString returns s as a JSON string literal. Control characters,
+// U+2028, U+2029 and invalid UTF-8 are escaped.
+func quoteString(s string) string {
+	result := make([]byte, 0, len(s)+2)
+	result = append(result, '"')
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			switch {
+			case b == '"' || b == '\\':
+				result = append(result, '\\', b)
+			case b == '\n':
+				result = append(result, '\\', 'n')
+			case b == '\r':
+				result = append(result, '\\', 'r')
+			case b == '\t':
+				result = append(result, '\\', 't')
+			case b == '\b':
+				result = append(result, '\\', 'b')
+			case b == '\f':
+				result = append(result, '\\', 'f')
+			case b < ' ':
+				result = append(result, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			default:
+				result = append(result, b)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			result = append(result, `\ufffd`...)
+		case r == '\u2028' || r == '\u2029':
+			result = append(result, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+		default:
+			result = append(result, s[i:i+size]...)
+		}
+		i += size
+	}
+	return string(append(result, '"'))
+}
@@ -0,0 +1,78 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyEscapes(t *testing.T) {
+	Convey("Decoding string escapes", t, func() {
+		Convey("Should decode every JSON escape sequence", func() {
+			m, _, err := ParseAsArrayOrSlice(`{"a\"b": "q\" s\\ sl\/ \b\f\n\r\t \u00e9 \ud83d\ude00 \ud83d", "empty": ""}`)
+			So(err, ShouldBeNil)
+			So(m[`a"b`].Value, ShouldEqual, "q\" s\\ sl/ \b\f\n\r\t é 😀 \ufffd")
+			So(m["empty"].Value, ShouldEqual, "")
+		})
+
+		Convey("Should decode escapes in arrays", func() {
+			_, arr, err := ParseAsArrayOrSlice(`["\"quoted\"", "", "tab\t"]`)
+			So(err, ShouldBeNil)
+			So(arr[0].Value, ShouldEqual, `"quoted"`)
+			So(arr[1].Value, ShouldEqual, "")
+			So(arr[2].Value, ShouldEqual, "tab\t")
+		})
+
+		Convey("Should reject broken strings", func() {
+			for _, input := range []string{`{"a": "\x"}`, `{"a": "\u12"}`, "{\"a\": \"line\nbreak\"}", `["a\"]`} {
+				_, _, err := ParseAsArrayOrSlice(input)
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Should keep backticks inside tag strings", func() {
+			m, _, err := ParseAsArrayOrSlice(`{"a": 1 ` + "`\"pattern\": \"^`\\\"\\u0041$\"`" + `}`)
+			So(err, ShouldBeNil)
+			ts, err := m["a"].Tags()
+			So(err, ShouldBeNil)
+			So(ts[0].Value, ShouldEqual, "^`\"A$")
+		})
+	})
+
+	Convey("Encoding string escapes", t, func() {
+		Convey("Should escape quotes, backslashes and control characters", func() {
+			r, err := Serialize([]Node{Node{Value: "q\" s\\ \n\r\t\b\f\x01 \u2028 é 😀 \xff"}}, true)
+			So(err, ShouldBeNil)
+			So(r, ShouldEqual, `["q\" s\\ \n\r\t\b\f\u0001 \u2028 é 😀 \ufffd"]`)
+		})
+
+		Convey("Should escape keys", func() {
+			r, _ := Serialize(map[string]Node{"a\"b\n": Node{Value: 1}}, true)
+			So(r, ShouldEqual, `{"a\"b\n":1}`)
+		})
+
+		Convey("Should escape tag strings", func() {
+			ts := TagSet{TagEntry{Key: "pattern", Value: "\"\\\n"}}
+			So(ts.String(), ShouldEqual, `"pattern": "\"\\\n"`)
+		})
+
+		Convey("Should round trip tricky payloads", func() {
+			payloads := []string{
+				"", `\`, `\\"`, `"`, "`", "line\nbreak", "\u0000\u001f", "😀🇺🇦", "\u2029", "mixed \"𝄞\" \\u0041",
+			}
+			for _, payload := range payloads {
+				m := map[string]Node{payload: Node{Value: payload, Tag: TagSet{TagEntry{Key: payload, Value: payload}}.String()}}
+				for _, trim := range []bool{true, false} {
+					r, err := Serialize(m, trim)
+					So(err, ShouldBeNil)
+					parsed, _, err := ParseAsArrayOrSlice(r)
+					So(err, ShouldBeNil)
+					So(parsed[payload].Value, ShouldEqual, payload)
+					ts, err := parsed[payload].Tags()
+					So(err, ShouldBeNil)
+					So(ts[0].Key, ShouldEqual, payload)
+					So(ts[0].Value, ShouldEqual, payload)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,292 @@
+package gojson
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// field is a struct field the way it appears in a gojson object.
+type field struct {
+	name      string // gojson key
+	goPath    string // Go path from the struct, like "Address.Street"
+	index     []int
+	tag       string // gojson tag taken from the struct tag
+	tagged    bool   // the name comes from the json tag
+	omitEmpty bool
+	quoted    bool   // ",string" option: the value is written as a string
+	layout    string // time layout from the gojson struct tag key
+}
+
+// structFields describes how a struct type maps to a gojson object.
+type structFields struct {
+	list []field
+	// inlineMap is the index of the map field with the inline option,
+	// which holds keys of the object not taken by the other fields.
+	inlineMap     []int
+	inlineMapPath string
+	// tags is the index of the field of type FieldTags.
+	tags []int
+}
+
+// typeFields returns the fields of struct type t. Options of the json
+// struct tag are handled the way encoding/json does: "-" skips the field,
+// "omitempty" omits empty values, "string" quotes strings, numbers and
+// bools. Unexported fields are skipped. Fields of anonymous struct fields
+// without a json name, and of struct fields with the "inline" option, are
+// put into the enclosing object following the encoding/json rules: of the
+// fields with the same name the least nested one wins, then the one named
+// by a json tag, and if there is still more than one, none of them is used.
+func typeFields(t reflect.Type) structFields {
+	var fields structFields
+	var candidates []field
+	type inlined struct {
+		typ    reflect.Type
+		index  []int
+		goPath string
+	}
+	var current []inlined
+	next := []inlined{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current, next = next, nil
+		// the same type twice at one level makes its fields ambiguous,
+		// so only the types of the upper levels are skipped
+		level := map[reflect.Type]bool{}
+		for _, s := range current {
+			if visited[s.typ] {
+				continue
+			}
+			level[s.typ] = true
+			for i := 0; i < s.typ.NumField(); i++ {
+				sf := s.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.PkgPath != "" && (!sf.Anonymous || ft.Kind() != reflect.Struct) {
+					continue
+				}
+				index := append(append([]int{}, s.index...), i)
+				goPath := joinPath(s.goPath, sf.Name)
+				if sf.Type == fieldTagsType {
+					if fields.tags == nil {
+						fields.tags = index
+					}
+					continue
+				}
+				jsonTag := sf.Tag.Get("json")
+				if jsonTag == "-" {
+					continue
+				}
+				name, opts := parseTagOptions(jsonTag)
+				inline := opts.contains("inline")
+				if ft.Kind() == reflect.Struct && (inline || sf.Anonymous && name == "") {
+					next = append(next, inlined{ft, index, goPath})
+					continue
+				}
+				if inline && ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String {
+					if fields.inlineMap == nil {
+						fields.inlineMap, fields.inlineMapPath = index, goPath
+					}
+					continue
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+				candidates = append(candidates, field{
+					name:      name,
+					goPath:    goPath,
+					index:     index,
+					tag:       gojsonTag(sf.Tag),
+					tagged:    name != "",
+					omitEmpty: opts.contains("omitempty"),
+					quoted:    opts.contains("string") && isQuotable(sf.Type),
+					layout:    structTagLayout(sf.Tag),
+				})
+				if name == "" {
+					candidates[len(candidates)-1].name = sf.Name
+				}
+			}
+		}
+		for typ := range level {
+			visited[typ] = true
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+		group := candidates[i:j]
+		if len(group) == 1 || len(group[0].index) < len(group[1].index) || group[0].tagged != group[1].tagged {
+			fields.list = append(fields.list, group[0])
+		}
+		i = j
+	}
+	sort.Slice(fields.list, func(i, j int) bool {
+		return lessIndex(fields.list[i].index, fields.list[j].index)
+	})
+	return fields
+}
+
+func lessIndex(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// tagOptions are the comma separated options after the name in a json
+// struct tag.
+type tagOptions string
+
+func parseTagOptions(tag string) (string, tagOptions) {
+	if i := strings.IndexByte(tag, ','); i != -1 {
+		return tag[:i], tagOptions(tag[i+1:])
+	}
+	return tag, ""
+}
+
+func (o tagOptions) contains(option string) bool {
+	for _, opt := range strings.Split(string(o), ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// gojsonTag returns the struct tag without its json and gojson keys. The
+// rest is the gojson tag of the field.
+func gojsonTag(tag reflect.StructTag) string {
+	s := string(tag)
+	for _, key := range []string{"json", "gojson"} {
+		s = removeTagKey(s, key)
+	}
+	return strings.TrimSpace(s)
+}
+
+// removeTagKey removes the key:"value" pair of the key from struct tag s.
+func removeTagKey(s, key string) string {
+	prefix := key + `:"`
+	for i := strings.Index(s, prefix); i != -1; {
+		if i == 0 || s[i-1] == ' ' {
+			value, err := strconv.QuotedPrefix(s[i+len(key)+1:])
+			if err != nil {
+				break
+			}
+			rest := s[i+len(key)+1+len(value):]
+			return strings.TrimSpace(strings.TrimSpace(s[:i]) + " " + strings.TrimSpace(rest))
+		}
+		next := strings.Index(s[i+1:], prefix)
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return s
+}
+
+// isQuotable reports whether the ",string" option applies to type t.
+func isQuotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldByIndex returns the field of struct v with the index, false if one
+// of the inlined structs on the way is a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndexAlloc returns the field of struct v with the index,
+// allocating nil pointers to inlined structs on the way. It returns false
+// if such a pointer can't be set, being an unexported embedded one.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether the omitempty option omits v.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// quoteValue turns the value of a field with the ",string" option into
+// the string holding its gojson representation.
+func quoteValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return getValue(v)
+}
+
+// unquoteValue parses the string written by quoteValue.
+func unquoteValue(v interface{}) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+	return parseValue([]byte(s))
+}
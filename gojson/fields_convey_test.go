@@ -0,0 +1,162 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"reflect"
+	"testing"
+)
+
+type fieldsAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street,omitempty" limit:"20"`
+}
+
+type fieldsPerson struct {
+	Name     string            `json:"name,omitempty" limit:"10"`
+	Secret   string            `json:"-"`
+	Dash     int               `json:"-,"`
+	Age      int               `json:"age,string"`
+	Admin    bool              `json:",string"`
+	Nick     *string           `json:"nick,omitempty"`
+	Address  *fieldsAddress    `json:",inline"`
+	City     string            `json:"city"`
+	Extra    map[string]string `json:",inline"`
+	Children []string          `json:"children,omitempty"`
+}
+
+func TestConveyFieldOptions(t *testing.T) {
+	Convey("Struct field options", t, func() {
+		Convey("The json part of a struct tag should be stripped from the gojson tag", func() {
+			fields := typeFields(reflect.TypeOf(fieldsAddress{}))
+			So(fields.list[0].tag, ShouldEqual, "")
+			So(fields.list[1].tag, ShouldEqual, `limit:"20"`)
+			So(gojsonTag(`a:"1" json:"x,omitempty" b:"2"`), ShouldEqual, `a:"1" b:"2"`)
+			So(gojsonTag(`xjson:"1" json:"x"`), ShouldEqual, `xjson:"1"`)
+		})
+
+		Convey("Serializing should follow the options", func() {
+			p := fieldsPerson{
+				Secret:  "s",
+				Dash:    1,
+				Age:     30,
+				Admin:   true,
+				Address: &fieldsAddress{City: "Kyiv", Street: "Main"},
+				City:    "Lviv",
+				Extra:   map[string]string{"note": "hi", "city": "Odesa"},
+			}
+			re, err := SerializeStruct(p, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"-":1,"age":"30","Admin":"true","street":"Main"`+
+				"`limit:\"20\"`"+`,"city":"Lviv","note":"hi"}`)
+		})
+
+		Convey("Parsing should follow the options", func() {
+			src := `{"name": "Joe", "-": 2, "Secret": "s", "age": "31", "Admin": "true", "city": "Lviv",
+				"street": "Main", "note": "hi", "other": "x"}`
+			p := fieldsPerson{}
+			So(ParseToStruct(&p, src), ShouldBeNil)
+			So(p.Name, ShouldEqual, "Joe")
+			So(p.Secret, ShouldEqual, "")
+			So(p.Dash, ShouldEqual, 2)
+			So(p.Age, ShouldEqual, 31)
+			So(p.Admin, ShouldBeTrue)
+			So(p.City, ShouldEqual, "Lviv")
+			So(p.Address, ShouldResemble, &fieldsAddress{Street: "Main"})
+			So(p.Extra, ShouldResemble, map[string]string{"Secret": "s", "note": "hi", "other": "x"})
+		})
+
+		Convey("Round trip should keep the value", func() {
+			nick := "jo"
+			p := fieldsPerson{Name: "Joe", Age: 5, Nick: &nick, Address: &fieldsAddress{Street: "Main"}, City: "Lviv",
+				Extra: map[string]string{"note": "hi"}, Children: []string{"Ann"}}
+			data, err := Marshal(p)
+			So(err, ShouldBeNil)
+			back := fieldsPerson{}
+			So(Unmarshal(data, &back), ShouldBeNil)
+			So(back, ShouldResemble, p)
+		})
+	})
+}
+
+type fieldsBase struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Comment string
+	hidden  string
+}
+
+type fieldsMeta struct {
+	Comment string
+	Version int `json:"Version"`
+}
+
+type fieldsAudit struct {
+	Version string
+	By      string `json:"by"`
+}
+
+type fieldsLoop struct {
+	*fieldsLoop
+	Depth int `json:"depth"`
+}
+
+type fieldsDoc struct {
+	fieldsBase
+	*fieldsMeta
+	fieldsAudit
+	Title string `json:"title"`
+	Name  string `json:"name"`
+	notes []string
+}
+
+func TestConveyEmbeddedFields(t *testing.T) {
+	Convey("Unexported and embedded fields", t, func() {
+		Convey("Field names should follow the encoding/json promotion rules", func() {
+			var names []string
+			for _, f := range typeFields(reflect.TypeOf(fieldsDoc{})).list {
+				names = append(names, f.goPath+"="+f.name)
+			}
+			// Comment is ambiguous, tagged version beats untagged one and
+			// name of the outer struct beats the promoted one
+			So(names, ShouldResemble, []string{
+				"fieldsBase.ID=id", "fieldsMeta.Version=Version", "fieldsAudit.By=by", "Title=title", "Name=name",
+			})
+			So(len(typeFields(reflect.TypeOf(fieldsLoop{})).list), ShouldEqual, 1)
+		})
+
+		Convey("Serializing should skip unexported fields and flatten embedded ones", func() {
+			doc := fieldsDoc{
+				fieldsBase:  fieldsBase{ID: 1, Name: "inner", Comment: "c", hidden: "h"},
+				fieldsAudit: fieldsAudit{By: "Joe"},
+				Title:       "T",
+				Name:        "outer",
+				notes:       []string{"n"},
+			}
+			re, err := SerializeStruct(doc, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"id":1,"by":"Joe","title":"T","name":"outer"}`)
+			doc.fieldsMeta = &fieldsMeta{Version: 2}
+			data, err := Marshal(doc)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"id":1,"Version":2,"by":"Joe","title":"T","name":"outer"}`)
+		})
+
+		Convey("Parsing should fill embedded fields", func() {
+			doc := fieldsDoc{}
+			src := `{"id": 3, "name": "outer", "Comment": "c", "Version": 4, "by": "Ann", "hidden": "h", "notes": ["n"]}`
+			So(ParseToStruct(&doc, src), ShouldBeNil)
+			So(doc.ID, ShouldEqual, 3)
+			So(doc.Name, ShouldEqual, "outer")
+			So(doc.fieldsBase.Name, ShouldEqual, "")
+			So(doc.fieldsBase.Comment, ShouldEqual, "")
+			So(doc.By, ShouldEqual, "Ann")
+			So(doc.hidden, ShouldEqual, "")
+			So(doc.notes, ShouldBeNil)
+			// pointer to an unexported embedded struct can't be allocated
+			So(doc.fieldsMeta, ShouldBeNil)
+			doc.fieldsMeta = &fieldsMeta{}
+			So(Unmarshal([]byte(src), &doc), ShouldBeNil)
+			So(doc.fieldsMeta.Version, ShouldEqual, 4)
+		})
+	})
+}
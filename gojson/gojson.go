@@ -1,9 +1,16 @@
 package gojson
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,175 +24,516 @@ type Node struct {
 }
 
 type mapData struct {
-	InQuotes     bool
 	Key          []byte
-	InKey        bool
 	Value        interface{}
 	InValue      bool
 	Tag          []byte
-	InTag        bool
-	PrevChar     byte
 	AfterCol     bool
 	Type         string
+	Start        int // offset of an unquoted value
 	AfterClosing bool
 }
 
-// Parses gojson into the struct or slice. Target value for parsing is being passed by pointer.
-// Uses json tag as key optional reference in gojson. Doesn't resets tags of the target struct.
-// TODO: Parameter whether or not to fill target struct with source tags.
+// Parses gojson into the struct, slice, array, map or interface{}. Target value for parsing is
+// being passed by pointer. Uses json tag as key optional reference in gojson. Doesn't resets
+// tags of the target struct. Maps may have string, integer or encoding.TextUnmarshaler keys.
+// Source tags are kept in fields of type FieldTags, see also ParseToStructWithOptions.
 func ParseToStruct(struc interface{}, gojson string) error {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println(r)
-		}
-	}()
-	v := reflect.ValueOf(struc)
+	return ParseToStructWithOptions(struc, gojson, DecoderOptions{})
+}
+
+// ParseToStructWithTags is ParseToStructWithOptions with only the TagSink
+// option set.
+func ParseToStructWithTags(struc interface{}, gojson string, sink TagSink) error {
+	return ParseToStructWithOptions(struc, gojson, DecoderOptions{TagSink: sink})
+}
+
+// DecoderOptions tune decoding into structs. Violations of the strict
+// options are not reported one by one: the decoding goes on and returns a
+// *FieldsError listing all of them.
+type DecoderOptions struct {
+	// DisallowUnknownFields reports object keys which match no struct
+	// field. Keys taken by an inline map field are not unknown.
+	DisallowUnknownFields bool
+	// RequireAllFields reports struct fields without a key in the object,
+	// except omitempty ones.
+	RequireAllFields bool
+	// CaseInsensitiveKeys lets struct fields match object keys
+	// case-insensitively when there is no exact match.
+	CaseInsensitiveKeys bool
+	// TagSink receives the tag of every tagged value keyed by its Go path
+	// like "Sister.Colors[1]". Nil TagSink records nothing.
+	TagSink TagSink
+}
+
+// ParseToStructWithOptions works like ParseToStruct with the decoding
+// options, which may be combined freely.
+func ParseToStructWithOptions(struc interface{}, gojson string, opts DecoderOptions) error {
 	m, arr, err := ParseAsArrayOrSlice(gojson)
 	if err != nil {
 		return err
 	}
-	if v.Kind() != reflect.Ptr {
+	return parseNodesToStruct(struc, m, arr, newDecodeState(opts))
+}
+
+// TagSink receives gojson tags of decoded values keyed by their Go path:
+// field names, map keys and slice indexes like "Sister.Colors[1]".
+type TagSink map[string]string
+
+// FieldTags holds gojson tags of the sibling fields of a struct keyed by their
+// gojson keys. A struct field of type FieldTags is filled with the source tags
+// on parse and its tags are written on serialization, taking precedence
+// over the struct tags of the fields. The field itself is never a key.
+type FieldTags map[string]string
+
+var fieldTagsType = reflect.TypeOf(FieldTags(nil))
+
+func parseNodesToStruct(struc interface{}, m map[string]Node, arr []Node, d *decodeState) error {
+	v := reflect.ValueOf(struc)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
 		return errors.New("gojson.ParseToStruct - TypeError. Parse to non-pointer value.")
 	}
-	switch v.Elem().Kind() {
-	case reflect.Struct:
-		parseAsStruct(v, m)
-	case reflect.Slice:
-		parseAsStructSlice(v, arr)
-	default:
-		return errors.New("gojson.ParseToStruct - TypeError. Parse target pointer should point to Struct or Slice.")
+	var root interface{}
+	if m != nil {
+		root = m
+	} else if arr != nil {
+		root = arr
 	}
+	return d.decode(v.Elem(), root)
+}
 
+// decode stores root into v and reports the violations of strict options.
+func (d *decodeState) decode(v reflect.Value, root interface{}) error {
+	if err := d.setStructValue(v, Node{Value: root}, decodePath{}); err != nil {
+		return err
+	}
+	if len(d.unknown) != 0 || len(d.missing) != 0 {
+		sort.Strings(d.unknown)
+		sort.Strings(d.missing)
+		return &FieldsError{Unknown: d.unknown, Missing: d.missing}
+	}
 	return nil
 }
 
-func parseAsStruct(v reflect.Value, source map[string]Node) error {
-	val := v.Elem()
-	t := val.Type()
-	n := t.NumField()
+// decodeState keeps settings of a single ParseToStruct or Unmarshal call.
+type decodeState struct {
+	// foldKeys lets struct fields match object keys case-insensitively
+	// when there is no exact match, as encoding/json does.
+	foldKeys bool
+	// sink receives tags of decoded values when it isn't nil.
+	sink TagSink
+	// timeLayout is the layout of time.Time values, DefaultTimeLayout if
+	// empty, and fieldLayout the one of the struct field being decoded.
+	timeLayout  string
+	fieldLayout string
+	// disallowUnknown and requireAll make parseAsStruct collect paths of
+	// unknown keys and missing fields into unknown and missing.
+	disallowUnknown bool
+	requireAll      bool
+	unknown         []string
+	missing         []string
+}
 
-	for i := 0; i < n; i++ {
-		field := t.Field(i)
-		jsonTag := field.Tag.Get("json")
-		name := field.Name
-		if jsonTag != "" {
-			name = jsonTag
+func newDecodeState(opts DecoderOptions) *decodeState {
+	return &decodeState{
+		sink:            opts.TagSink,
+		foldKeys:        opts.CaseInsensitiveKeys,
+		disallowUnknown: opts.DisallowUnknownFields,
+		requireAll:      opts.RequireAllFields,
+	}
+}
+
+// decodePath is the location of a decoded value both in the gojson value
+// and in the Go value.
+type decodePath struct {
+	key    string // like "sister.colors[1]", used in errors
+	goPath string // like "Sister.Colors[1]", used in TagSink
+}
+
+func (p decodePath) field(key, goPath string) decodePath {
+	return decodePath{joinPath(p.key, key), p.inline(goPath).goPath}
+}
+
+// inline returns the path of a field which holds values of the same object.
+func (p decodePath) inline(goPath string) decodePath {
+	if p.goPath != "" {
+		goPath = p.goPath + "." + goPath
+	}
+	return decodePath{p.key, goPath}
+}
+
+func (p decodePath) mapKey(key string) decodePath {
+	return decodePath{joinPath(p.key, key), joinPath(p.goPath, key)}
+}
+
+func (p decodePath) index(i int) decodePath {
+	return decodePath{indexPath(p.key, i), indexPath(p.goPath, i)}
+}
+
+func (d *decodeState) parseAsStruct(val reflect.Value, source map[string]Node, path decodePath) error {
+	fields := typeFields(val.Type())
+	used := make(map[string]bool, len(source))
+	for _, field := range fields.list {
+		key, node, exist := d.lookup(source, field.name)
+		if !exist {
+			if d.requireAll && !field.omitEmpty {
+				d.missing = append(d.missing, joinPath(path.key, field.name))
+			}
+			continue
 		}
-		if n, exist := source[name]; exist {
-			f := val.FieldByName(field.Name)
-			setStructValue(f, n.Value)
+		used[key] = true
+		f, ok := fieldByIndexAlloc(val, field.index)
+		if !ok || !f.CanSet() {
+			continue
+		}
+		if field.quoted {
+			var err error
+			if node.Value, err = unquoteValue(node.Value); err != nil {
+				return &UnmarshalTypeError{Path: path.field(key, field.goPath).key, GoType: f.Type(), GoJSONType: "string"}
+			}
+		}
+		layout := d.fieldLayout
+		d.fieldLayout = field.layout
+		err := d.setStructValue(f, node, path.field(key, field.goPath))
+		d.fieldLayout = layout
+		if err != nil {
+			return err
+		}
+	}
+	if fields.inlineMap != nil {
+		rest := make(map[string]Node, len(source)-len(used))
+		for key, node := range source {
+			if !used[key] {
+				rest[key] = node
+			}
+		}
+		if f, ok := fieldByIndexAlloc(val, fields.inlineMap); ok && f.CanSet() {
+			if err := d.parseAsStructMap(f, rest, path.inline(fields.inlineMapPath)); err != nil {
+				return err
+			}
+		}
+	}
+	if fields.inlineMap == nil && d.disallowUnknown {
+		for _, key := range sortedKeys(source) {
+			if !used[key] {
+				d.unknown = append(d.unknown, joinPath(path.key, key))
+			}
+		}
+	}
+	if fields.tags != nil {
+		if f, ok := fieldByIndexAlloc(val, fields.tags); ok && f.CanSet() {
+			f.Set(reflect.ValueOf(sourceTags(source)))
 		}
 	}
 	return nil
 }
 
-func parseAsStructSlice(f reflect.Value, source []Node) error {
-	fType := f.Type()
-	fElemType := f.Type().Elem()
-	slice := reflect.MakeSlice(fType, 0, 0)
-	for _, value := range source {
-		v := reflect.ValueOf(value.Value)
-		switch v.Kind() {
-		case reflect.Slice:
-			sl := reflect.MakeSlice(v.Type(), 0, 0)
-			sourceSl := nodeSlice(value.Value)
-			parseAsStructSlice(sl, sourceSl)
-			slice = reflect.Append(slice, sl)
-		case reflect.Map:
-			mp := nodeMap(value.Value)
-			if fElemType.Kind() == reflect.Struct {
-				val := reflect.New(fElemType)
-				parseAsStruct(val, mp)
-				slice = reflect.Append(slice, val.Elem())
-			} else if fElemType.Kind() == reflect.Ptr {
-				val := reflect.New(f.Elem().Elem().Type())
-				parseAsStruct(val, mp)
-				slice = reflect.Append(slice, val)
-			} else {
-				newMap := reflect.MakeMap(v.Type())
-				parseAsStructMap(newMap, mp)
-				slice = reflect.Append(slice, v)
-			}
-		default:
-			slice = reflect.Append(slice, v)
+// sourceTags returns the tags of an object keyed by its keys, nil if
+// none of its values is tagged.
+func sourceTags(source map[string]Node) FieldTags {
+	var tags FieldTags
+	for key, node := range source {
+		if node.Tag == "" {
+			continue
+		}
+		if tags == nil {
+			tags = FieldTags{}
+		}
+		tags[key] = node.Tag
+	}
+	return tags
+}
+
+// lookup finds the key and the node of a struct field by its name.
+func (d *decodeState) lookup(source map[string]Node, name string) (string, Node, bool) {
+	if node, exist := source[name]; exist || !d.foldKeys {
+		return name, node, exist
+	}
+	for _, key := range sortedKeys(source) {
+		if strings.EqualFold(key, name) {
+			return key, source[key], true
+		}
+	}
+	return "", Node{}, false
+}
+
+func (d *decodeState) parseAsStructSlice(f reflect.Value, source []Node, path decodePath) error {
+	slice := reflect.MakeSlice(f.Type(), len(source), len(source))
+	for i, node := range source {
+		if err := d.setStructValue(slice.Index(i), node, path.index(i)); err != nil {
+			return err
 		}
 	}
 	f.Set(slice)
 	return nil
 }
 
-func parseAsStructMap(f reflect.Value, source map[string]Node) error {
+// parseAsStructArray fills the array f with the source values. Elements
+// beyond the source are set to zero and extra source values are dropped.
+func (d *decodeState) parseAsStructArray(f reflect.Value, source []Node, path decodePath) error {
+	for i := 0; i < f.Len(); i++ {
+		if i >= len(source) {
+			f.Index(i).Set(reflect.Zero(f.Type().Elem()))
+			continue
+		}
+		if err := d.setStructValue(f.Index(i), source[i], path.index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decodeState) parseAsStructMap(f reflect.Value, source map[string]Node, path decodePath) error {
 	fType := f.Type()
-	fElemType := f.Type().Elem()
-	m := reflect.MakeMap(fType)
-	for key, value := range source {
-		v := reflect.ValueOf(value.Value)
-		switch v.Kind() {
-		case reflect.Slice:
-			sl := reflect.MakeSlice(v.Type(), 0, 0)
-			sourceSl := nodeSlice(value.Value)
-			parseAsStructSlice(sl, sourceSl)
-			m.SetMapIndex(reflect.ValueOf(key), sl)
-		case reflect.Map:
-			mp := nodeMap(value.Value)
-			if fElemType.Kind() == reflect.Struct {
-				val := reflect.New(fElemType)
-				parseAsStruct(val, mp)
-				m.SetMapIndex(reflect.ValueOf(key), val.Elem())
-			} else if fElemType.Kind() == reflect.Ptr {
-				val := reflect.New(f.Elem().Elem().Type())
-				parseAsStruct(val, mp)
-				m.SetMapIndex(reflect.ValueOf(key), val)
-			} else {
-				newMap := reflect.MakeMap(v.Type())
-				parseAsStructMap(newMap, mp)
-				m.SetMapIndex(reflect.ValueOf(key), newMap)
-			}
-		default:
-			m.SetMapIndex(reflect.ValueOf(key), v)
+	if !isMapKeyType(fType.Key()) {
+		return &UnmarshalTypeError{Path: path.key, GoType: fType, GoJSONType: "object"}
+	}
+	if f.IsNil() {
+		f.Set(reflect.MakeMap(fType))
+	}
+	for key, node := range source {
+		k, err := mapKey(fType.Key(), key)
+		if err != nil {
+			return &UnmarshalTypeError{Path: path.mapKey(key).key, GoType: fType.Key(), GoJSONType: "string"}
+		}
+		elem := reflect.New(fType.Elem()).Elem()
+		if err := d.setStructValue(elem, node, path.mapKey(key)); err != nil {
+			return err
 		}
+		f.SetMapIndex(k, elem)
 	}
-	f.Set(m)
 	return nil
 }
 
-func setStructValue(f reflect.Value, newValue interface{}) error {
-	if f.IsValid() {
-		if f.CanSet() {
-			switch f.Kind() {
-			case reflect.Ptr:
-				f = f.Elem()
-				fallthrough
-			case reflect.Struct:
-				if source, ok := newValue.(map[string]Node); ok {
-					return parseAsStruct(f, source)
-				}
-			case reflect.Slice:
-				if source, ok := newValue.([]Node); ok {
-					return parseAsStructSlice(f, source)
-				}
-			case reflect.Map:
-				if source, ok := newValue.(map[string]Node); ok {
-					return parseAsStructMap(f, source)
-				}
-			default:
-				f.Set(reflect.ValueOf(newValue))
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isMapKeyType reports whether object keys can be stored into or written
+// from map keys of type t: strings, integers and text (un)marshalers.
+func isMapKeyType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return reflect.PtrTo(t).Implements(textUnmarshalerType) || t.Implements(textMarshalerType)
+}
+
+// mapKey converts an object key into a map key of type t.
+func mapKey(t reflect.Type, key string) (reflect.Value, error) {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		k := reflect.New(t)
+		if err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return k.Elem(), nil
+	}
+	k := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(i) {
+			return reflect.Value{}, fmt.Errorf("gojson: invalid map key %q", key)
+		}
+		k.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(u) {
+			return reflect.Value{}, fmt.Errorf("gojson: invalid map key %q", key)
+		}
+		k.SetUint(u)
+	default:
+		return reflect.Value{}, fmt.Errorf("gojson: invalid map key %q", key)
+	}
+	return k, nil
+}
+
+// mapKeyString returns the object key of a map key: strings as they are,
+// then encoding.TextMarshaler text, then integers in decimal.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &UnsupportedTypeError{GoType: k.Type()}
+}
+
+// setStructValue stores the node value into f, which may be of any kind.
+// Null sets pointers, maps, slices and interfaces to nil and leaves other
+// values as they are.
+func (d *decodeState) setStructValue(f reflect.Value, node Node, path decodePath) error {
+	if node.Tag != "" && d.sink != nil {
+		d.sink[path.goPath] = node.Tag
+	}
+	if node.Value == nil {
+		switch f.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			f.Set(reflect.Zero(f.Type()))
+			return nil
+		}
+	}
+	if node.Value != nil {
+		if ok, err := d.setTime(f, node, path); ok {
+			return err
+		}
+	}
+	if ok, err := unmarshal(f, node, path); ok {
+		return err
+	}
+	if node.Value == nil {
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.Ptr:
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		return d.setStructValue(f.Elem(), node, path)
+	case reflect.Interface:
+		if f.NumMethod() == 0 {
+			f.Set(reflect.ValueOf(plainValue(node.Value)))
+			return nil
+		}
+	case reflect.Struct:
+		if source, ok := objectNodes(node.Value); ok {
+			return d.parseAsStruct(f, source, path)
+		}
+	case reflect.Array:
+		if source, ok := node.Value.([]Node); ok {
+			return d.parseAsStructArray(f, source, path)
+		}
+	case reflect.Slice:
+		if source, ok := node.Value.([]Node); ok {
+			return d.parseAsStructSlice(f, source, path)
+		}
+		if s, ok := node.Value.(string); ok && f.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
 			}
+			f.SetBytes(b)
+			return nil
+		}
+	case reflect.Map:
+		if source, ok := objectNodes(node.Value); ok {
+			return d.parseAsStructMap(f, source, path)
+		}
+	case reflect.String:
+		if s, ok := node.Value.(string); ok {
+			f.SetString(s)
+			return nil
+		}
+		if f.Type() == numberType && setNumber(f, node.Value) {
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := node.Value.(bool); ok {
+			f.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if setNumber(f, node.Value) {
+			return nil
 		}
 	}
-	return nil
+	return &UnmarshalTypeError{Path: path.key, GoType: f.Type(), GoJSONType: valueKind(node.Value)}
+}
+
+// valueKind names the gojson kind of a value, the one it is written as.
+func valueKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]Node, *OrderedMap:
+		return "object"
+	case []Node:
+		return "array"
+	case string, time.Time, time.Duration:
+		return "string"
+	case bool:
+		return "bool"
+	case nil:
+		return "null"
+	case Number:
+		return "number"
+	}
+	if node, ok, err := marshalerNode(v); ok && err == nil {
+		return valueKind(node.Value)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "null"
+		}
+		return valueKind(rv.Elem().Interface())
+	}
+	return "number"
+}
+
+// plainValue converts a parsed value into the one encoding/json would
+// store into an empty interface: map[string]interface{}, []interface{},
+// float64, string, bool or nil. Tags are dropped.
+func plainValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]Node, *OrderedMap:
+		nodes, _ := objectNodes(v)
+		m := make(map[string]interface{}, len(nodes))
+		for key, node := range nodes {
+			m[key] = plainValue(node.Value)
+		}
+		return m
+	case []Node:
+		s := make([]interface{}, len(v))
+		for i, node := range v {
+			s[i] = plainValue(node.Value)
+		}
+		return s
+	case int:
+		return float64(v)
+	}
+	return v
 }
 
 // SerializeStruct serializes gojson string using any struct or []struct.
 // Similar to "encoding/json" package it will take json struct tag as a
 // key of json property if it exists. Also, it will ignore json tag value in
 // gojson tag serialization. So `json: "..."` will never be used in gojson.
+// Options of the json tag work as in "encoding/json" (omitempty, "-" and
+// string), plus inline which puts fields of a struct field, or entries of
+// a map field, into the enclosing object. Unexported fields are skipped and
+// fields of embedded structs are promoted by the "encoding/json" rules.
 func SerializeStruct(s interface{}, trim bool) (string, error) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println(r)
-		}
-	}()
 	stucV := reflect.ValueOf(s)
 	r, err := getNode(s, stucV)
 	if err != nil {
@@ -195,43 +543,72 @@ func SerializeStruct(s interface{}, trim bool) (string, error) {
 	return Serialize(r.Value, trim)
 }
 
-func getMapFromStruct(s interface{}) (map[string]Node, error) {
-	result := make(map[string]Node)
+// getMapFromStruct returns the object of a struct with keys in the order
+// of the fields, as encoding/json writes them. Keys of an inline map
+// follow sorted.
+func (e *encodeState) getMapFromStruct(s interface{}) (*OrderedMap, error) {
+	result := NewOrderedMap()
 	stucV := reflect.ValueOf(s)
-	stucT := reflect.TypeOf(s)
-	n := stucT.NumField()
-
-	for i := 0; i < n; i++ {
-		var err error
-		field := stucT.Field(i)
-		jsonTag := field.Tag.Get("json")
-		tag := string(field.Tag)
-		name := field.Name
-		if jsonTag != "" {
-			name = jsonTag
-			tag = strings.TrimSpace(
-				strings.Replace(tag, `json:`+`"`+name+`"`, "", -1),
-			)
-		}
-		value := stucV.Field(i).Interface()
-		stucVV := reflect.ValueOf(value)
-		node, err := getNode(value, stucVV)
+	fields := typeFields(stucV.Type())
+
+	for _, field := range fields.list {
+		f, ok := fieldByIndex(stucV, field.index)
+		if !ok || !f.CanInterface() || field.omitEmpty && isEmptyValue(f) {
+			continue
+		}
+		node, err := e.getNode(f.Interface(), f)
 		if err != nil {
 			return nil, err
 		}
-		if tag != "" {
-			node.Tag = tag
+		if field.layout != "" {
+			node = formatTimes(node, field.layout)
+		}
+		if field.quoted {
+			if err := checkFinite(node.Value); err != nil {
+				return nil, err
+			}
+			node.Value = quoteValue(node.Value)
+		}
+		if field.tag != "" {
+			node.Tag = field.tag
+		}
+		result.Set(field.name, node)
+	}
+	if fields.inlineMap != nil {
+		if f, ok := fieldByIndex(stucV, fields.inlineMap); ok && f.CanInterface() && !f.IsNil() {
+			keys := f.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, key := range keys {
+				if _, exist := result.Get(key.String()); exist {
+					continue
+				}
+				value := f.MapIndex(key)
+				node, err := e.getNode(value.Interface(), value)
+				if err != nil {
+					return nil, err
+				}
+				result.Set(key.String(), node)
+			}
+		}
+	}
+	if fields.tags != nil {
+		if f, ok := fieldByIndex(stucV, fields.tags); ok && f.CanInterface() {
+			for key, tag := range f.Interface().(FieldTags) {
+				if node, exist := result.Get(key); exist {
+					node.Tag = tag
+					result.Set(key, node)
+				}
+			}
 		}
-		result[name] = node
 	}
 	return result, nil
 }
 
-func getSlice(items []interface{}) ([]Node, error) {
+func (e *encodeState) getSlice(items []interface{}) ([]Node, error) {
 	result := []Node{}
 	for _, item := range items {
 		stucV := reflect.ValueOf(item)
-		node, err := getNode(item, stucV)
+		node, err := e.getNode(item, stucV)
 		if err != nil {
 			return nil, err
 		}
@@ -241,38 +618,145 @@ func getSlice(items []interface{}) ([]Node, error) {
 	return result, nil
 }
 
+// encodeState holds the pointers, maps and slices on the way from the root
+// to the value being encoded, so cyclic values are reported instead of
+// recursing forever.
+type encodeState struct {
+	seen map[visit]bool
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// getNode converts any Go value into a Node the way SerializeStruct does.
 func getNode(item interface{}, stucV reflect.Value) (Node, error) {
+	e := &encodeState{seen: map[visit]bool{}}
+	return e.getNode(item, stucV)
+}
+
+// enter marks v as being encoded. It returns false if v is already on the
+// way to itself, the returned func unmarks it.
+func (e *encodeState) enter(v reflect.Value) (func(), bool) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if e.seen[key] {
+		return nil, false
+	}
+	e.seen[key] = true
+	return func() { delete(e.seen, key) }, true
+}
+
+// cycleError is the error of a value which contains itself.
+func cycleError(v reflect.Value) error {
+	return &UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String()}
+}
+
+func (e *encodeState) getNode(item interface{}, stucV reflect.Value) (Node, error) {
 	v := Node{}
 	var err error
 
+	switch it := item.(type) {
+	case map[string]Node, *OrderedMap, []Node:
+		v.Value = item
+		return v, nil
+	case time.Time:
+		v.Value = item
+		return v, nil
+	case big.Int:
+		v.Value = Number(it.String())
+		return v, nil
+	case *big.Int:
+		if it != nil {
+			v.Value = Number(it.String())
+		}
+		return v, nil
+	}
+	if stucV.Kind() == reflect.Ptr && stucV.IsNil() {
+		return v, nil
+	}
+	if node, ok, err := marshalerNode(item); ok {
+		return node, err
+	}
 	switch stucV.Kind() {
+	case reflect.Invalid:
+	case reflect.Ptr, reflect.Interface:
+		if stucV.IsNil() {
+			return v, nil
+		}
+		if stucV.Kind() == reflect.Ptr {
+			leave, ok := e.enter(stucV)
+			if !ok {
+				return v, cycleError(stucV)
+			}
+			defer leave()
+		}
+		return e.getNode(stucV.Elem().Interface(), stucV.Elem())
 	case reflect.Struct:
-		v.Value, err = getMapFromStruct(item)
+		v.Value, err = e.getMapFromStruct(item)
 		if err != nil {
 			return v, err
 		}
 	case reflect.Slice:
-		val := interfaceSlice(item)
-		v.Value, err = getSlice(val)
+		if stucV.IsNil() {
+			return v, nil
+		}
+		if stucV.Type().Elem().Kind() == reflect.Uint8 {
+			v.Value = base64.StdEncoding.EncodeToString(stucV.Bytes())
+			return v, nil
+		}
+		if stucV.Len() != 0 {
+			leave, ok := e.enter(stucV)
+			if !ok {
+				return v, cycleError(stucV)
+			}
+			defer leave()
+		}
+		fallthrough
+	case reflect.Array:
+		val, err := interfaceSlice(item)
+		if err != nil {
+			return v, err
+		}
+		v.Value, err = e.getSlice(val)
 		if err != nil {
 			return v, err
 		}
 	case reflect.Map:
-		val := interfaceMap(item)
-		v.Value, err = getMap(val)
+		if stucV.IsNil() {
+			return v, nil
+		}
+		if stucV.Len() != 0 {
+			leave, ok := e.enter(stucV)
+			if !ok {
+				return v, cycleError(stucV)
+			}
+			defer leave()
+		}
+		val, err := interfaceMap(item)
 		if err != nil {
-			return v, errors.New("Only map[string]interface{} is acceptable")
+			return v, err
 		}
+		v.Value, err = e.getMap(val)
+		if err != nil {
+			return v, err
+		}
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return v, &UnsupportedTypeError{GoType: stucV.Type()}
 	default:
 		v.Value = item
 	}
 	return v, nil
 }
 
-func interfaceSlice(slice interface{}) []interface{} {
+func interfaceSlice(slice interface{}) ([]interface{}, error) {
 	s := reflect.ValueOf(slice)
-	if s.Kind() != reflect.Slice {
-		panic("Non-slice value in interfaceSlice argument")
+	if s.Kind() != reflect.Slice && s.Kind() != reflect.Array {
+		return nil, &UnsupportedTypeError{GoType: s.Type()}
 	}
 
 	ret := make([]interface{}, s.Len())
@@ -281,13 +765,13 @@ func interfaceSlice(slice interface{}) []interface{} {
 		ret[i] = s.Index(i).Interface()
 	}
 
-	return ret
+	return ret, nil
 }
 
-func interfaceMap(m interface{}) map[string]interface{} {
+func interfaceMap(m interface{}) (map[string]interface{}, error) {
 	s := reflect.ValueOf(m)
-	if s.Kind() != reflect.Map {
-		panic("Non-map value in interfaceSlice argument")
+	if s.Kind() != reflect.Map || !isMapKeyType(s.Type().Key()) {
+		return nil, &UnsupportedTypeError{GoType: s.Type()}
 	}
 
 	ret := make(map[string]interface{}, s.Len())
@@ -296,53 +780,22 @@ func interfaceMap(m interface{}) map[string]interface{} {
 
 	for _, key := range keys {
 		value := s.MapIndex(key)
-		ret[key.String()] = value.Interface()
-	}
-
-	return ret
-}
-
-func nodeSlice(m interface{}) []Node {
-	s := reflect.ValueOf(m)
-	if s.Kind() != reflect.Slice {
-		panic("Non-slice value in nodeSlice argument")
-	}
-
-	ret := make([]Node, s.Len())
-
-	for i := 0; i < s.Len(); i++ {
-		ret[i] = s.Index(i).Interface().(Node)
-	}
-
-	return ret
-}
-
-func nodeMap(m interface{}) map[string]Node {
-	s := reflect.ValueOf(m)
-	if s.Kind() != reflect.Map {
-		panic("Non-map value in nodeMap argument")
-	}
-
-	ret := make(map[string]Node, s.Len())
-
-	keys := s.MapKeys()
-
-	for _, key := range keys {
-		value := s.MapIndex(key)
-		if n, isOk := value.Interface().(Node); isOk {
-			ret[key.String()] = n
+		k, err := mapKeyString(key)
+		if err != nil {
+			return nil, err
 		}
+		ret[k] = value.Interface()
 	}
 
-	return ret
+	return ret, nil
 }
 
-func getMap(items map[string]interface{}) (map[string]Node, error) {
+func (e *encodeState) getMap(items map[string]interface{}) (map[string]Node, error) {
 	result := make(map[string]Node)
 
 	for key, item := range items {
 		stucV := reflect.ValueOf(item)
-		node, err := getNode(item, stucV)
+		node, err := e.getNode(item, stucV)
 		if err != nil {
 			return nil, err
 		}
@@ -357,156 +810,174 @@ func getMap(items map[string]interface{}) (map[string]Node, error) {
 // map[string]Data{} (if Value if JSON object {}), []Data{} if value is
 // JSON array and nil if value if JSON null.
 func ParseAsArrayOrSlice(str string) (map[string]Node, []Node, error) {
-	if strings.HasPrefix(str, "null") {
-		return nil, nil, nil
-	} else if str[0] == '{' {
-		result, _, err := parseAsMap([]byte(str), 1)
-		return result, nil, err
-	} else if str[0] == '[' {
-		result, _, err := parseAsSlice([]byte(str), 1)
-		return nil, result, err
+	root, err := ParseWithOptions(str, ParseOptions{})
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil, nil, syntaxError(0, str[0])
+	m, arr := splitRoot(root)
+	return m, arr, nil
+}
+
+// ParseOptions tunes the values the parser produces.
+type ParseOptions struct {
+	// OrderedMaps makes the parser return objects as *OrderedMap with keys
+	// in the source order instead of map[string]Node.
+	OrderedMaps bool
+	// UseNumber makes the parser return numbers as Number instead of int
+	// and float64.
+	UseNumber bool
+
+	// strict rejects unquoted strings, as Unmarshal does.
+	strict bool
+}
+
+// ParseWithOptions parses gojson like ParseAsArrayOrSlice does and returns
+// the root value, which is map[string]Node (or *OrderedMap), []Node or nil.
+func ParseWithOptions(str string, opts ParseOptions) (interface{}, error) {
+	root, _, err := parseBytes([]byte(str), opts)
+	return root, err
 }
 
-func parseAsMap(str []byte, c int) (map[string]Node, int, error) {
-	node := map[string]Node{}
+// parseBytes is ParseWithOptions over bytes which also returns the offset
+// right after the root value. It doesn't keep references to data.
+func parseBytes(data []byte, opts ParseOptions) (interface{}, int, error) {
+	c := len(data) - len(bytes.TrimLeftFunc(data, unicode.IsSpace))
+	if c == len(data) {
+		return nil, c, syntaxError(data, c, "'{', '[' or null")
+	} else if bytes.HasPrefix(data[c:], []byte("null")) {
+		return nil, c + len("null"), nil
+	} else if data[c] == '{' {
+		result, end, err := parseAsMap(data, c+1, opts)
+		return result, end + 1, err
+	} else if data[c] == '[' {
+		result, end, err := parseAsSlice(data, c+1, opts)
+		if err != nil {
+			return nil, end, err
+		}
+		return result, end + 1, nil
+	}
+	return nil, c, syntaxError(data, c, "'{', '[' or null")
+}
+
+// splitRoot converts the root value returned by ParseWithOptions into
+// results of ParseAsArrayOrSlice.
+func splitRoot(root interface{}) (map[string]Node, []Node) {
+	switch v := root.(type) {
+	case map[string]Node:
+		return v, nil
+	case *OrderedMap:
+		return v.Map(), nil
+	case []Node:
+		return nil, v
+	}
+	return nil, nil
+}
+
+func parseAsMap(str []byte, c int, opts ParseOptions) (interface{}, int, error) {
+	node := NewOrderedMap()
 	m := mapData{}
 	var err error
 
 	//we can do byte iteration because special JSON chars has always 1 byte length
 	for c < len(str) {
-		if str[c] == '"' && !m.InTag {
-			if !m.InKey && len(m.Key) > 0 && !m.AfterCol {
-				return nil, c, syntaxError(c, str[c])
-			}
-			if m.PrevChar == '\\' {
-				if m.InKey {
-					m.Key = append(m.Key, str[c])
-				} else if m.InValue {
-					if m.Value == nil {
-						m.Value = []byte{str[c]}
-					} else {
-						v := (m.Value).([]byte)
-						m.Value = append(v, str[c])
-					}
-				}
+		if str[c] == '"' {
+			start := c
+			var s []byte
+			s, c, err = scanString(str, c)
+			if err != nil {
+				return nil, c, err
 			}
-			if m.Value != nil {
-				m.InValue = false
-			} else if len(m.Key) != 0 {
-				if m.InKey {
-					m.InKey = false
-				} else {
-					m.InValue = true
-					m.Type = "string"
-				}
+			if m.Key == nil {
+				m.Key = s
+			} else if !m.AfterCol {
+				return nil, start, syntaxError(str, start, "':'")
+			} else if m.Value != nil {
+				return nil, start, syntaxError(str, start, "',' or '}'")
 			} else {
-				m.InKey = true
+				m.Value = s
+				m.Type = "string"
 			}
-			m.InQuotes = !m.InQuotes
-		} else if !m.InQuotes && !m.InTag {
-			if str[c] == '`' {
-				if m.Key != nil {
-					m.InTag = true
-				} else {
-					return nil, c, syntaxError(c, str[c])
-				}
-			} else if str[c] == '}' {
-				if m.Key != nil {
-					m.AfterClosing = true
-					if m.Type == "" {
-						m.Type, err = detectType(&m)
-						if err != nil {
-							return nil, c, err
-						}
-					}
-					err := createPair(node, &m)
+		} else if str[c] == '`' {
+			if m.Key == nil {
+				return nil, c, syntaxError(str, c, "object key")
+			}
+			m.Tag, c, err = scanTag(str, c)
+			if err != nil {
+				return nil, c, err
+			}
+		} else if str[c] == '}' {
+			if m.Key != nil {
+				m.AfterClosing = true
+				if m.Type == "" {
+					m.Type, err = detectType(str, c, &m, opts)
 					if err != nil {
 						return nil, c, err
 					}
-				} else {
-					return nil, c, syntaxError(c, str[c])
 				}
-				return node, c, nil
-			} else if str[c] == '{' {
-				c++
-				m.Value, c, err = parseAsMap(str, c)
+				err := createPair(str, c, node, &m, opts)
 				if err != nil {
 					return nil, c, err
 				}
-				m.Type = "map"
-			} else if str[c] == '[' {
-				c++
-				m.Value, c, err = parseAsSlice(str, c)
-				m.Type = "slice"
-			} else if str[c] == ':' {
-				m.AfterCol = true
-			} else if str[c] == ',' {
-				if m.Type == "" {
-					m.Type, err = detectType(&m)
-					if err != nil {
-						return nil, c, err
-					}
-				}
-				err := createPair(node, &m)
+			} else if node.Len() != 0 {
+				return nil, c, syntaxError(str, c, "object key")
+			}
+			return objectValue(node, opts), c, nil
+		} else if str[c] == '{' {
+			c++
+			m.Value, c, err = parseAsMap(str, c, opts)
+			if err != nil {
+				return nil, c, err
+			}
+			m.Type = "map"
+		} else if str[c] == '[' {
+			c++
+			m.Value, c, err = parseAsSlice(str, c, opts)
+			if err != nil {
+				return nil, c, err
+			}
+			m.Type = "slice"
+		} else if str[c] == ':' {
+			if m.Key == nil {
+				return nil, c, syntaxError(str, c, "object key")
+			} else if m.Value != nil {
+				return nil, c, syntaxError(str, c, "',' or '}'")
+			} else if m.AfterCol {
+				return nil, c, syntaxError(str, c, "value")
+			}
+			m.AfterCol = true
+		} else if str[c] == ',' {
+			if m.Type == "" {
+				m.Type, err = detectType(str, c, &m, opts)
 				if err != nil {
 					return nil, c, err
 				}
-				reset(&m)
-			} else if str[c] == '`' {
-				m.InTag = !m.InTag
-			} else {
-				r, _ := utf8.DecodeRune([]byte{str[c]})
-				if !unicode.IsSpace(r) {
-					if m.InValue {
-						if m.Value == nil {
-							m.Value = []byte{str[c]}
-						} else {
-							v := (m.Value).([]byte)
-							m.Value = append(v, str[c])
-						}
-					} else if m.Key != nil && !m.InValue && m.AfterCol && m.Value == nil {
-						m.InValue = true
-						if m.Value == nil {
-							m.Value = []byte{str[c]}
-						} else {
-							v := (m.Value).([]byte)
-							m.Value = append(v, str[c])
-						}
-					} else {
-
-						return nil, c, syntaxError(c, str[c])
-					}
-				} else {
-					if m.InValue {
-						m.InValue = false
-					}
-				}
 			}
-		} else if m.InTag {
-			if str[c] == '`' {
-				m.InTag = false
-			} else {
-				m.Tag = append(m.Tag, str[c])
+			err := createPair(str, c, node, &m, opts)
+			if err != nil {
+				return nil, c, err
 			}
+			reset(&m)
 		} else {
-			if str[c] == '\\' {
-				m.PrevChar = '\\'
-			} else {
-				m.PrevChar = 0
-			}
-			if m.InKey {
-				m.Key = append(m.Key, str[c])
-			} else if m.InValue {
-				if m.Value == nil {
-					m.Value = []byte{str[c]}
-				} else {
+			r, _ := utf8.DecodeRune([]byte{str[c]})
+			if !unicode.IsSpace(r) {
+				if m.InValue {
 					v := (m.Value).([]byte)
 					m.Value = append(v, str[c])
+				} else if m.Key != nil && m.AfterCol && m.Value == nil {
+					m.InValue = true
+					m.Value = []byte{str[c]}
+					m.Start = c
+				} else if m.Key == nil {
+					return nil, c, syntaxError(str, c, "object key")
+				} else if !m.AfterCol {
+					return nil, c, syntaxError(str, c, "':'")
+				} else {
+					return nil, c, syntaxError(str, c, "',' or '}'")
 				}
 			} else {
-				return nil, c, syntaxError(c, str[c])
+				if m.InValue {
+					m.InValue = false
+				}
 			}
 		}
 		c++
@@ -514,193 +985,148 @@ func parseAsMap(str []byte, c int) (map[string]Node, int, error) {
 
 	if m.AfterClosing {
 		if m.Key != nil {
-			createPair(node, &m)
+			createPair(str, c, node, &m, opts)
 			reset(&m)
 		}
-		return node, c, nil
-	} else {
-		return nil, 0, errors.New("Invalid JSON")
+		return objectValue(node, opts), c, nil
 	}
-	return node, c, nil
+	return nil, c, syntaxError(str, c, "'}'")
 }
 
-func detectType(m *mapData) (string, error) {
-	bytes := (m.Value).([]byte)
+func detectType(str []byte, c int, m *mapData, opts ParseOptions) (string, error) {
+	bytes, _ := (m.Value).([]byte)
 	val := string(bytes)
 	if val == "" {
-		return "", errors.New("Syntax error")
+		return "", syntaxError(str, c, "value")
 	}
-	if v, isOk := strconv.ParseFloat(val, 64); isOk == nil {
-		if isIntegral(v) {
-			return "int", nil
+	if isNumber(val) {
+		if strings.ContainsAny(val, ".eE") {
+			return "float64", nil
 		}
-		return "float64", nil
+		return "int", nil
 	}
 	if isBoolean(val) {
 		return "bool", nil
 	}
+	if val == "null" {
+		return "null", nil
+	}
+	if opts.strict {
+		return "", syntaxError(str, m.Start, "value")
+	}
 	return "string", nil
 }
 
-func createPair(node map[string]Node, m *mapData) error {
-	pair := Node{
-		Tag: string(m.Tag),
+// objectValue returns the parsed object in the form requested by opts.
+func objectValue(node *OrderedMap, opts ParseOptions) interface{} {
+	if opts.OrderedMaps {
+		return node
 	}
-	if m.Type == "map" || m.Type == "slice" {
-		pair.Value = m.Value
-		node[string(m.Key)] = pair
-		return nil
-	}
-	bytes := (m.Value).([]byte)
-	val := string(bytes)
-	switch m.Type {
-	case "int":
-		v, _ := strconv.ParseFloat(val, 64)
-		pair.Value = int(v)
-		node[string(m.Key)] = pair
-	case "float64":
-		v, _ := strconv.ParseFloat(val, 64)
-		pair.Value = v
-		node[string(m.Key)] = pair
-	case "bool":
-		if val == "true" {
-			pair.Value = true
-			node[string(m.Key)] = pair
-		} else {
-			pair.Value = false
-			node[string(m.Key)] = pair
-		}
-	case "string":
-		pair.Value = val
-		node[string(m.Key)] = pair
-	default:
-		return errors.New("Wrong JSON syntax")
+	return node.values
+}
+
+func createPair(str []byte, c int, node *OrderedMap, m *mapData, opts ParseOptions) error {
+	pair, err := createValue(str, c, m, opts)
+	if err != nil {
+		return err
 	}
+	node.Set(string(m.Key), pair)
 	return nil
 }
 
-func parseAsSlice(str []byte, c int) ([]Node, int, error) {
+func parseAsSlice(str []byte, c int, opts ParseOptions) ([]Node, int, error) {
 	node := []Node{}
 	m := mapData{}
 	var err error
 
 	//we can do byte iteration because special JSON chars has always 1 byte length
 	for c < len(str) {
-		if str[c] == '"' && !m.InTag {
-			if m.PrevChar == '\\' {
-				if m.InValue {
-					if m.Value == nil {
-						m.Value = []byte{str[c]}
-					} else {
-						v := (m.Value).([]byte)
-						m.Value = append(v, str[c])
-					}
-				}
+		if str[c] == '"' {
+			start := c
+			var s []byte
+			s, c, err = scanString(str, c)
+			if err != nil {
+				return nil, c, err
 			}
-			if m.Value != nil {
-				if !m.InQuotes {
-					return nil, c, syntaxError(c, str[c])
-				}
-				m.InValue = false
-			} else {
-				m.InValue = true
-				m.Type = "string"
+			if m.Value != nil || m.Type != "" {
+				return nil, start, syntaxError(str, start, "',' or ']'")
 			}
-			m.InQuotes = !m.InQuotes
-		} else if !m.InQuotes && !m.InTag {
-			if str[c] == '`' {
-				if m.Value != nil || m.Type == "string" {
-					m.InTag = true
-				} else {
-					return nil, c, syntaxError(c, str[c])
-				}
-			} else if str[c] == ']' {
-				if m.Value != nil || m.Type == "string" {
-					m.AfterClosing = true
-					if m.Type == "" {
-						m.Type, err = detectType(&m)
-						if err != nil {
-							return nil, c, err
-						}
-					}
-					pair, err := createValue(&m)
-					if err != nil {
-						return nil, c, err
-					}
-					node = append(node, pair)
-				} else {
-					return nil, c, syntaxError(c, str[c])
-				}
-				return node, c, nil
-			} else if str[c] == '{' {
-				c++
-				m.Value, c, err = parseAsMap(str, c)
-				if err != nil {
-					return nil, c, err
-				}
-				m.Type = "map"
-			} else if str[c] == '[' {
-				c++
-				m.Value, c, err = parseAsSlice(str, c)
-				if err != nil {
-					return nil, c, err
-				}
-				m.Type = "slice"
-			} else if str[c] == ':' {
-				m.AfterCol = true
-			} else if str[c] == ',' {
+			m.Value = s
+			m.Type = "string"
+		} else if str[c] == '`' {
+			if m.Value == nil {
+				return nil, c, syntaxError(str, c, "value")
+			}
+			m.Tag, c, err = scanTag(str, c)
+			if err != nil {
+				return nil, c, err
+			}
+		} else if str[c] == ']' {
+			if m.Value != nil {
+				m.AfterClosing = true
 				if m.Type == "" {
-					m.Type, err = detectType(&m)
+					m.Type, err = detectType(str, c, &m, opts)
 					if err != nil {
 						return nil, c, err
 					}
 				}
-				pair, err := createValue(&m)
+				pair, err := createValue(str, c, &m, opts)
 				if err != nil {
 					return nil, c, err
 				}
 				node = append(node, pair)
-				reset(&m)
-			} else if str[c] == '`' {
-				m.InTag = !m.InTag
-			} else {
-				r, _ := utf8.DecodeRune([]byte{str[c]})
-				if !unicode.IsSpace(r) {
-					if m.InValue && m.Value == nil {
-						m.InValue = true
-					} else {
-						return nil, c, syntaxError(c, str[c])
-					}
-					if m.Value == nil {
-						m.Value = []byte{str[c]}
-					} else {
-						v := (m.Value).([]byte)
-						m.Value = append(v, str[c])
-					}
-				} else {
-					if m.InValue {
-						m.InValue = false
-					}
+			} else if len(node) != 0 {
+				return nil, c, syntaxError(str, c, "value")
+			}
+			return node, c, nil
+		} else if str[c] == '{' {
+			c++
+			m.Value, c, err = parseAsMap(str, c, opts)
+			if err != nil {
+				return nil, c, err
+			}
+			m.Type = "map"
+		} else if str[c] == '[' {
+			c++
+			m.Value, c, err = parseAsSlice(str, c, opts)
+			if err != nil {
+				return nil, c, err
+			}
+			m.Type = "slice"
+		} else if str[c] == ':' {
+			if m.Value != nil {
+				return nil, c, syntaxError(str, c, "',' or ']'")
+			}
+			return nil, c, syntaxError(str, c, "value")
+		} else if str[c] == ',' {
+			if m.Type == "" {
+				m.Type, err = detectType(str, c, &m, opts)
+				if err != nil {
+					return nil, c, err
 				}
 			}
-		} else if m.InTag {
-			if str[c] == '`' {
-				m.InTag = false
-			} else {
-				m.Tag = append(m.Tag, str[c])
+			pair, err := createValue(str, c, &m, opts)
+			if err != nil {
+				return nil, c, err
 			}
+			node = append(node, pair)
+			reset(&m)
 		} else {
-			if str[c] == '\\' {
-				m.PrevChar = '\\'
-			} else {
-				m.PrevChar = 0
-			}
-			if m.InValue {
-				if m.Value == nil {
-					m.Value = []byte{str[c]}
-				} else {
+			r, _ := utf8.DecodeRune([]byte{str[c]})
+			if !unicode.IsSpace(r) {
+				if m.InValue {
 					v := (m.Value).([]byte)
 					m.Value = append(v, str[c])
+				} else if m.Value == nil && m.Type == "" {
+					m.InValue = true
+					m.Value = []byte{str[c]}
+					m.Start = c
+				} else {
+					return nil, c, syntaxError(str, c, "',' or ']'")
+				}
+			} else {
+				if m.InValue {
+					m.InValue = false
 				}
 			}
 		}
@@ -709,7 +1135,7 @@ func parseAsSlice(str []byte, c int) ([]Node, int, error) {
 
 	if m.AfterClosing {
 		if m.Key != nil {
-			pair, err := createValue(&m)
+			pair, err := createValue(str, c, &m, opts)
 			if err != nil {
 				return nil, c, err
 			}
@@ -717,13 +1143,11 @@ func parseAsSlice(str []byte, c int) ([]Node, int, error) {
 			reset(&m)
 		}
 		return node, c, nil
-	} else {
-		return nil, 0, errors.New("Invalid JSON")
 	}
-	return node, c, nil
+	return nil, c, syntaxError(str, c, "']'")
 }
 
-func createValue(m *mapData) (Node, error) {
+func createValue(str []byte, c int, m *mapData, opts ParseOptions) (Node, error) {
 	pair := Node{
 		Tag: string(m.Tag),
 	}
@@ -735,11 +1159,17 @@ func createValue(m *mapData) (Node, error) {
 	val := string(bytes)
 	switch m.Type {
 	case "int":
-		v, _ := strconv.ParseFloat(val, 64)
-		pair.Value = int(v)
+		if v, err := strconv.ParseInt(val, 10, 0); err == nil && !opts.UseNumber {
+			pair.Value = int(v)
+		} else {
+			pair.Value = Number(val)
+		}
 	case "float64":
-		v, _ := strconv.ParseFloat(val, 64)
-		pair.Value = v
+		if v, err := strconv.ParseFloat(val, 64); err == nil && !opts.UseNumber {
+			pair.Value = v
+		} else {
+			pair.Value = Number(val)
+		}
 	case "bool":
 		if val == "true" {
 			pair.Value = true
@@ -748,229 +1178,202 @@ func createValue(m *mapData) (Node, error) {
 		}
 	case "string":
 		pair.Value = val
+	case "null":
+		pair.Value = nil
 	default:
-		return pair, errors.New("Wrong JSON syntax")
+		return pair, syntaxError(str, c, "value")
 	}
 	return pair, nil
 }
 
-func isIntegral(val float64) bool {
-	return val == float64(int(val))
-}
-
 func isBoolean(val string) bool {
 	return val == "true" || val == "false"
 }
 
-func syntaxError(c int, crc byte) error {
-	return errors.New(fmt.Sprintf("Syntax error at %d - char %s", c, string(crc)))
-}
-
 func reset(data *mapData) {
 	*data = mapData{}
 }
 
-//SerializeMap transforms map[string]Node into gojson string, trim parameter
-//responsible for turning on/off whitespacing inside json string.
+// SerializeMap transforms map[string]Node into gojson string, trim parameter
+// responsible for turning on/off whitespacing inside json string.
 func Serialize(m interface{}, trim bool) (string, error) {
 	config := serializeConfig{
-		Trim:       trim,
-		BasicSpace: 4,
+		Trim:   trim,
+		Indent: "    ",
+	}
+	var b strings.Builder
+	if err := serialize(&b, m, config); err != nil {
+		return "", err
 	}
+	return b.String(), nil
+}
+
+type serializeConfig struct {
+	Trim       bool
+	Prefix     string
+	Indent     string
+	SortKeys   bool
+	StripTags  bool
+	TimeLayout string
+}
+
+// serializeWriter is implemented by both *strings.Builder and *bufio.Writer,
+// so the same code serves Serialize and Encoder.
+type serializeWriter interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+func serialize(w serializeWriter, m interface{}, c serializeConfig) error {
 	switch v := m.(type) {
 	case map[string]Node:
-		return serializeMap(v, config, 0)
+		return serializeMap(w, v, c, 0)
+	case *OrderedMap:
+		return serializeOrderedMap(w, v, c, 0)
 	case []Node:
-		return serializeSlice(v, config, 0)
+		return serializeSlice(w, v, c, 0)
 	default:
-		return "", errors.New(`Error upon serialization - wrong input type`)
+		return errors.New(`Error upon serialization - wrong input type`)
 	}
 }
 
-type serializeConfig struct {
-	Trim       bool
-	BasicSpace int
-}
-
-func serializeMap(m map[string]Node, c serializeConfig, ns int) (string, error) {
-	i := 0
-	result := "{"
-	row := ""
-	if !c.Trim {
-		result += "\n"
-		ns += c.BasicSpace
-	}
-	for key, node := range m {
-		switch v := node.Value.(type) {
-		case map[string]Node:
-			value, err := serializeMap(v, c, ns)
-			if c.Trim {
-				row = fmt.Sprintf(`"%s":%s`, key, value)
-			} else {
-				row = strings.Repeat(" ", ns) + row
-				row += fmt.Sprintf(`"%s": %s `, key, value)
-			}
-			if node.Tag != "" {
-				if !c.Trim {
-					row += fmt.Sprintf(" `%s`", node.Tag)
-				} else {
-					row += fmt.Sprintf("`%s`", node.Tag)
-				}
-			}
-			if err != nil {
-				return "", err
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
-		case []Node:
-			value, err := serializeSlice(v, c, ns)
-			if c.Trim {
-				row = fmt.Sprintf(`"%s":%s`, key, value)
-			} else {
-				row = strings.Repeat(" ", ns) + row
-				row += fmt.Sprintf(`"%s": %s`, key, value)
-			}
-			if node.Tag != "" {
-				if !c.Trim {
-					row += fmt.Sprintf(" `%s`", node.Tag)
-				} else {
-					row += fmt.Sprintf("`%s`", node.Tag)
-				}
-			}
-			if err != nil {
-				return "", err
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
-		default:
-			row = createRow(key, node, c.Trim)
-			if !c.Trim {
-				row = strings.Repeat(" ", ns) + row
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
+// serializeMap writes keys of the map sorted, so the output is always the same.
+func serializeMap(w serializeWriter, m map[string]Node, c serializeConfig, depth int) error {
+	return serializeMembers(w, sortedKeys(m), m, c, depth)
+}
+
+func serializeOrderedMap(w serializeWriter, m *OrderedMap, c serializeConfig, depth int) error {
+	keys := m.keys
+	if c.SortKeys {
+		keys = sortedKeys(m.values)
+	}
+	return serializeMembers(w, keys, m.values, c, depth)
+}
+
+func serializeMembers(w serializeWriter, keys []string, m map[string]Node, c serializeConfig, depth int) error {
+	w.WriteByte('{')
+	for i, key := range keys {
+		if i != 0 {
+			w.WriteByte(',')
 		}
+		writeNewline(w, c, depth+1)
+		w.WriteString(quoteString(key) + ":")
 		if !c.Trim {
-			row += "\n"
+			w.WriteByte(' ')
+		}
+		if err := serializeNode(w, m[key], c, depth+1); err != nil {
+			return err
 		}
-		result += row
-		row = ""
-		i++
 	}
-	if !c.Trim {
-		result += strings.Repeat(" ", ns-c.BasicSpace) + "}"
-	} else {
-		result += "}"
+	if len(keys) != 0 {
+		writeNewline(w, c, depth)
 	}
-	return result, nil
+	w.WriteByte('}')
+	return nil
 }
 
-func serializeSlice(m []Node, c serializeConfig, ns int) (string, error) {
-	i := 0
-	result := "["
-	row := ""
-	if !c.Trim {
-		result += "\n"
-		ns += c.BasicSpace
-	}
-	for _, node := range m {
-		switch v := node.Value.(type) {
-		case map[string]Node:
-			value, err := serializeMap(v, c, ns)
-			if c.Trim {
-				row = fmt.Sprintf(`%s`, value)
-			} else {
-				row = strings.Repeat(" ", ns) + row
-				row += fmt.Sprintf(`%s`, value)
-			}
-			if err != nil {
-				return "", err
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
-		case []Node:
-			value, err := serializeSlice(v, c, ns)
-			if c.Trim {
-				row = fmt.Sprintf(`%s`, value)
-			} else {
-				row = strings.Repeat(" ", ns) + row
-				row += fmt.Sprintf(`%s`, value)
-			}
-			if err != nil {
-				return "", err
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
-		default:
-			row = createElement(node, c.Trim)
-			if !c.Trim {
-				row = strings.Repeat(" ", ns) + row
-			}
-			if i != len(m)-1 {
-				row += ","
-			}
+func serializeSlice(w serializeWriter, m []Node, c serializeConfig, depth int) error {
+	w.WriteByte('[')
+	for i, node := range m {
+		if i != 0 {
+			w.WriteByte(',')
 		}
-		if !c.Trim {
-			row += "\n"
+		writeNewline(w, c, depth+1)
+		if err := serializeNode(w, node, c, depth+1); err != nil {
+			return err
 		}
-		result += row
-		row = ""
-		i++
 	}
-	if !c.Trim {
-		result += strings.Repeat(" ", ns-c.BasicSpace) + "]"
-	} else {
-		result += "]"
+	if len(m) != 0 {
+		writeNewline(w, c, depth)
 	}
-	return result, nil
+	w.WriteByte(']')
+	return nil
 }
 
-func createRow(key string, node Node, trim bool) string {
-	value := getValue(node.Value)
-	if node.Tag != "" {
-		if trim {
-			value = fmt.Sprintf(`%s`+"`%s`", value, node.Tag)
-		} else {
-			value = fmt.Sprintf(`%s `+"`%s`", value, node.Tag)
+func serializeNode(w serializeWriter, node Node, c serializeConfig, depth int) error {
+	var err error
+	switch v := node.Value.(type) {
+	case map[string]Node:
+		err = serializeMap(w, v, c, depth)
+	case *OrderedMap:
+		err = serializeOrderedMap(w, v, c, depth)
+	case []Node:
+		err = serializeSlice(w, v, c, depth)
+	case time.Time:
+		w.WriteString(quoteString(v.Format(timeLayout(formatLayouts[tagFormat(node.Tag)], c.TimeLayout))))
+	case time.Duration:
+		w.WriteString(quoteString(v.String()))
+	case Number:
+		if !isNumber(string(v)) {
+			return fmt.Errorf("gojson: invalid number literal %q", string(v))
+		}
+		w.WriteString(string(v))
+	default:
+		var n Node
+		var ok bool
+		n, ok, err = marshalerNode(v)
+		if !ok && isScalar(v) {
+			if err = checkFinite(v); err == nil {
+				w.WriteString(getValue(v))
+			}
+			break
+		}
+		if !ok {
+			// Go slices, maps and structs are encoded the way Marshal
+			// does, unsupported types fail
+			n, err = getNode(v, reflect.ValueOf(v))
+		}
+		if err == nil {
+			if node.Tag == "" {
+				node.Tag = n.Tag
+			}
+			err = serializeNode(w, Node{Value: n.Value}, c, depth)
 		}
 	}
-
-	if trim {
-		return fmt.Sprintf(`"%s":%s`, key, value)
+	if err != nil {
+		return err
 	}
-
-	return fmt.Sprintf(`"%s": %s`, key, value)
+	if node.Tag != "" && !c.StripTags {
+		if !c.Trim {
+			w.WriteByte(' ')
+		}
+		w.WriteString("`" + node.Tag + "`")
+	}
+	return nil
 }
 
-func createElement(node Node, trim bool) string {
-	value := getValue(node.Value)
-	if node.Tag != "" {
-		if trim {
-			value = fmt.Sprintf(`%s`+"`%s`", value, node.Tag)
-		} else {
-			value = fmt.Sprintf(`%s `+"`%s`", value, node.Tag)
-		}
+func writeNewline(w serializeWriter, c serializeConfig, depth int) {
+	if c.Trim {
+		return
 	}
+	w.WriteByte('\n')
+	w.WriteString(c.Prefix)
+	for i := 0; i < depth; i++ {
+		w.WriteString(c.Indent)
+	}
+}
 
-	return fmt.Sprintf(`%s`, value)
+// checkFinite returns UnsupportedValueError for NaN and infinite floats,
+// which have no gojson representation.
+func checkFinite(val interface{}) error {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+		return nil
+	}
+	if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+		return &UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, v.Type().Bits())}
+	}
+	return nil
 }
 
 func getValue(val interface{}) string {
-	needQuotes := false
 	value := ""
 	switch v := val.(type) {
 	case time.Time:
-		if v.String()[:4] == "0000" {
-			value = v.Format("15:04:05")
-		} else {
-			value = v.Format("2006-01-02 15:04:05")
-		}
-		needQuotes = true
+		value = quoteString(v.Format(DefaultTimeLayout))
+	case time.Duration:
+		value = quoteString(v.String())
 	case int:
 		value = fmt.Sprintf("%d", v)
 	case int32:
@@ -978,24 +1381,51 @@ func getValue(val interface{}) string {
 	case int64:
 		value = fmt.Sprintf("%d", v)
 	case float32:
-		value = fmt.Sprintf("%v", v)
+		value = formatFloat(float64(v), 32)
 	case float64:
-		value = fmt.Sprintf("%v", v)
+		value = formatFloat(v, 64)
+	case bool:
+		value = strconv.FormatBool(v)
+	case Number:
+		value = string(v)
 	case nil:
 		value = "null"
 	case string:
-		//sqlite interprets BIT type as a string so we need an explicit conversation
-		if _, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
-			value = fmt.Sprintf("%s", v)
-			break
-		}
-		value = strings.Replace(v, `"`, `\"`, -1)
-		needQuotes = true
+		value = quoteString(v)
 	default:
-		value = fmt.Sprintf("%s", v)
-	}
-	if needQuotes {
-		value = fmt.Sprintf(`"%s"`, value)
+		value = getKindValue(v)
 	}
 	return value
 }
+
+// isScalar reports whether getValue can format the value: nil, a string,
+// a bool or a number of any named or sized type.
+func isScalar(val interface{}) bool {
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Invalid, reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// getKindValue formats values of named and sized types by their kind,
+// other kinds never reach it, see isScalar.
+func getKindValue(val interface{}) string {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.String:
+		return quoteString(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(v.Float(), v.Type().Bits())
+	}
+	return fmt.Sprintf("%s", val)
+}
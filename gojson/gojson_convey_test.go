@@ -1,7 +1,10 @@
 package gojson
 
 import (
+	"errors"
 	. "github.com/smartystreets/goconvey/convey"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -220,3 +223,201 @@ func TestConveySerializeStruct(t *testing.T) {
 		})
 	})
 }
+
+func TestConveyParseToStructWithTags(t *testing.T) {
+	type Sister struct {
+		FieldTags
+		Name   string   `json:"name"`
+		Colors []string `json:"colors"`
+	}
+
+	type person struct {
+		Name   string            `json:"name"`
+		Sister *Sister           `json:"sister"`
+		Extra  map[string]string `json:"extra"`
+	}
+
+	src := `{
+		"name": "Joe" ` + "`\"editable\": false`" + `,
+		"sister": {"name": "Jessy" ` + "`\"unique\": true`" + `, "colors": ["red", "blue" ` + "`\"editable\": false`" + `]},
+		"extra": {"note": "hi" ` + "`\"max-length\": 10`" + `}
+	}`
+
+	Convey("Parsing to struct with tags", t, func() {
+		Convey("TagSink should receive tags by Go paths", func() {
+			obj := person{}
+			sink := TagSink{}
+			So(ParseToStructWithTags(&obj, src, sink), ShouldBeNil)
+			So(obj.Name, ShouldEqual, "Joe")
+			So(sink, ShouldResemble, TagSink{
+				"Name":             `"editable": false`,
+				"Sister.Name":      `"unique": true`,
+				"Sister.Colors[1]": `"editable": false`,
+				"Extra.note":       `"max-length": 10`,
+			})
+		})
+
+		Convey("Nil TagSink should be allowed", func() {
+			obj := person{}
+			So(ParseToStructWithTags(&obj, src, nil), ShouldBeNil)
+			So(obj.Sister.Name, ShouldEqual, "Jessy")
+		})
+
+		Convey("Fields of type FieldTags should be filled with tags of sibling keys", func() {
+			obj := person{}
+			So(ParseToStruct(&obj, src), ShouldBeNil)
+			So(obj.Sister.FieldTags, ShouldResemble, FieldTags{"name": `"unique": true`})
+			So(obj.Sister.Colors, ShouldResemble, []string{"red", "blue"})
+		})
+
+		Convey("FieldTags should be written back on serialization", func() {
+			obj := person{}
+			ParseToStruct(&obj, src)
+			obj.Sister.FieldTags["colors"] = `"list": ["red", "blue"]`
+			re, err := SerializeStruct(obj.Sister, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"name":"Jessy"`+"`\"unique\": true`"+
+				`,"colors":["red","blue"]`+"`\"list\": [\"red\", \"blue\"]`"+`}`)
+		})
+	})
+}
+
+type point struct{ X, Y int }
+
+func (p point) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(p.X) + ":" + strconv.Itoa(p.Y)), nil
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	if len(parts) != 2 {
+		return errors.New("point should be x:y")
+	}
+	p.X, _ = strconv.Atoi(parts[0])
+	p.Y, _ = strconv.Atoi(parts[1])
+	return nil
+}
+
+func TestConveyParseToAnyTarget(t *testing.T) {
+	Convey("Parsing to any pointer target", t, func() {
+		Convey("Should fill maps of any value type", func() {
+			var ages map[string]int
+			So(ParseToStruct(&ages, `{"joe": 42, "jessy": 17 `+"`\"number\": < 18`"+`}`), ShouldBeNil)
+			So(ages, ShouldResemble, map[string]int{"joe": 42, "jessy": 17})
+
+			var any map[string]interface{}
+			So(ParseToStruct(&any, `{"a": [1, "b", null], "c": {"d": true}}`), ShouldBeNil)
+			So(any, ShouldResemble, map[string]interface{}{
+				"a": []interface{}{1.0, "b", nil},
+				"c": map[string]interface{}{"d": true},
+			})
+		})
+
+		Convey("Should fill an empty interface the encoding/json way", func() {
+			var v interface{}
+			So(ParseToStruct(&v, `[{"a": 1.5}, 2]`), ShouldBeNil)
+			So(v, ShouldResemble, []interface{}{map[string]interface{}{"a": 1.5}, 2.0})
+		})
+
+		Convey("Should fill arrays", func() {
+			v := [3]string{"x", "y", "z"}
+			So(ParseToStruct(&v, `["a", "b"]`), ShouldBeNil)
+			So(v, ShouldResemble, [3]string{"a", "b", ""})
+			var short [1]int
+			So(ParseToStruct(&short, `[1, 2]`), ShouldBeNil)
+			So(short, ShouldResemble, [1]int{1})
+		})
+
+		Convey("Should convert keys of integer and TextUnmarshaler maps", func() {
+			var byID map[int]string
+			So(ParseToStruct(&byID, `{"1": "a", "-2": "b"}`), ShouldBeNil)
+			So(byID, ShouldResemble, map[int]string{1: "a", -2: "b"})
+
+			var small map[uint8]bool
+			err := ParseToStruct(&small, `{"256": true}`)
+			So(err, ShouldHaveSameTypeAs, &UnmarshalTypeError{})
+			So(err.(*UnmarshalTypeError).Path, ShouldEqual, "256")
+
+			var grid map[point]string
+			So(ParseToStruct(&grid, `{"1:2": "a"}`), ShouldBeNil)
+			So(grid, ShouldResemble, map[point]string{{1, 2}: "a"})
+
+			data, err := Marshal(map[point]int{{3, 4}: 1})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"3:4":1}`)
+			re, err := SerializeStruct(struct {
+				ByID map[int]string `json:"by_id"`
+			}{byID}, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"by_id":{"-2":"b","1":"a"}}`)
+		})
+
+		Convey("Should report a root of the wrong type", func() {
+			var v struct{}
+			err := ParseToStruct(&v, `[1]`)
+			So(err, ShouldResemble, &UnmarshalTypeError{GoType: reflect.TypeOf(v), GoJSONType: "array"})
+		})
+	})
+}
+
+func TestConveyStrictDecoding(t *testing.T) {
+	type Sister struct {
+		Name   string   `json:"name"`
+		Colors []string `json:"colors"`
+	}
+	type Person struct {
+		Name     string  `json:"name"`
+		Nickname string  `json:"nickname,omitempty"`
+		Sister   *Sister `json:"sister"`
+	}
+	src := `{"Name": "Joe", "age": 42, "sister": {"name": "Jessy", "age": 17, "eyes": "blue"}}`
+
+	Convey("Strict decoding", t, func() {
+		Convey("Should list every unknown and missing path", func() {
+			var p Person
+			err := ParseToStructWithOptions(&p, src, DecoderOptions{DisallowUnknownFields: true, RequireAllFields: true})
+			So(err, ShouldResemble, &FieldsError{
+				Unknown: []string{"Name", "age", "sister.age", "sister.eyes"},
+				Missing: []string{"name", "sister.colors"},
+			})
+			So(err.Error(), ShouldEqual, "gojson: unknown fields Name, age, sister.age, sister.eyes; "+
+				"missing fields name, sister.colors")
+			So(p.Sister.Name, ShouldEqual, "Jessy")
+		})
+
+		Convey("Should match keys case-insensitively if asked", func() {
+			var p Person
+			err := ParseToStructWithOptions(&p, src, DecoderOptions{RequireAllFields: true, CaseInsensitiveKeys: true})
+			So(err, ShouldResemble, &FieldsError{Missing: []string{"sister.colors"}})
+			So(p.Name, ShouldEqual, "Joe")
+
+			So(ParseToStruct(&Person{}, src), ShouldBeNil)
+		})
+
+		Convey("Should not report keys of an inline map", func() {
+			var v struct {
+				Name string                 `json:"name"`
+				Rest map[string]interface{} `json:",inline"`
+			}
+			So(ParseToStructWithOptions(&v, `{"name": "Joe", "age": 42}`, DecoderOptions{DisallowUnknownFields: true}), ShouldBeNil)
+			So(v.Rest, ShouldResemble, map[string]interface{}{"age": 42.0})
+		})
+
+		Convey("Decoder should honour the options", func() {
+			dec := NewDecoder(strings.NewReader(`{"name": "Joe", "sister": null} {"name": "Jessy", "age": 17}`))
+			dec.SetDecoderOptions(DecoderOptions{DisallowUnknownFields: true})
+			var p Person
+			So(dec.Decode(&p), ShouldBeNil)
+			So(dec.Decode(&p), ShouldResemble, &FieldsError{Unknown: []string{"age"}})
+		})
+
+		Convey("Should combine with a TagSink", func() {
+			var p Person
+			sink := TagSink{}
+			src := `{"name": "Joe" ` + "`\"editable\": false`" + `, "sister": null, "age": 42}`
+			err := ParseToStructWithOptions(&p, src, DecoderOptions{DisallowUnknownFields: true, TagSink: sink})
+			So(err, ShouldResemble, &FieldsError{Unknown: []string{"age"}})
+			So(sink, ShouldResemble, TagSink{"Name": `"editable": false`})
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package gojson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToJSON converts map[string]Node, *OrderedMap or []Node into plain JSON
+// and a sidecar JSON object which maps JSON Pointers of tagged nodes to
+// their tags, e.g. {"/sister/name": "\"unique\": true"}. FromJSON puts
+// the two back together.
+func ToJSON(root interface{}) ([]byte, []byte, error) {
+	data, err := StripTags(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := NewOrderedMap()
+	collectTags(root, "", tags)
+	var b strings.Builder
+	if err := serialize(&b, tags, serializeConfig{Trim: true}); err != nil {
+		return nil, nil, err
+	}
+	return data, []byte(b.String()), nil
+}
+
+// StripTags converts map[string]Node, *OrderedMap or []Node into plain
+// JSON dropping all the tags.
+func StripTags(root interface{}) ([]byte, error) {
+	var b strings.Builder
+	if err := serialize(&b, root, serializeConfig{Trim: true, StripTags: true}); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// FromJSON parses plain JSON and attaches tags from the sidecar produced by
+// ToJSON. Empty sidecar means no tags. Results are the same as the ones of
+// ParseAsArrayOrSlice.
+func FromJSON(data, sidecar []byte) (map[string]Node, []Node, error) {
+	m, arr, err := ParseAsArrayOrSlice(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sidecar) == 0 {
+		return m, arr, nil
+	}
+	tags, _, err := ParseAsArrayOrSlice(string(sidecar))
+	if err != nil {
+		return nil, nil, err
+	}
+	var root interface{} = arr
+	if m != nil {
+		root = m
+	}
+	for _, pointer := range sortedKeys(tags) {
+		tag, ok := tags[pointer].Value.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("gojson: tag of %q in sidecar should be a string", pointer)
+		}
+		tokens, err := splitPointer(pointer)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := setTag(root, tokens, tag); err != nil {
+			return nil, nil, fmt.Errorf("gojson: can't tag %q: %s", pointer, err)
+		}
+	}
+	return m, arr, nil
+}
+
+func collectTags(value interface{}, pointer string, tags *OrderedMap) {
+	switch v := value.(type) {
+	case map[string]Node, *OrderedMap:
+		nodes, _ := objectNodes(v)
+		for _, key := range objectKeys(v) {
+			collectNodeTags(nodes[key], joinPointer(pointer, key), tags)
+		}
+	case []Node:
+		for i, node := range v {
+			collectNodeTags(node, joinPointer(pointer, strconv.Itoa(i)), tags)
+		}
+	}
+}
+
+func collectNodeTags(node Node, pointer string, tags *OrderedMap) {
+	if node.Tag != "" {
+		tags.Set(pointer, Node{Value: node.Tag})
+	}
+	collectTags(node.Value, pointer, tags)
+}
+
+// setTag sets the tag of the node which tokens lead to inside of value.
+func setTag(value interface{}, tokens []string, tag string) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("root value can't have a tag")
+	}
+	switch v := value.(type) {
+	case map[string]Node, *OrderedMap:
+		nodes, _ := objectNodes(v)
+		node, exist := nodes[tokens[0]]
+		if !exist {
+			return fmt.Errorf("no key %q", tokens[0])
+		}
+		if len(tokens) == 1 {
+			node.Tag = tag
+			nodes[tokens[0]] = node
+			return nil
+		}
+		return setTag(node.Value, tokens[1:], tag)
+	case []Node:
+		i, err := strconv.Atoi(tokens[0])
+		if err != nil || i < 0 || i >= len(v) {
+			return fmt.Errorf("no index %q", tokens[0])
+		}
+		if len(tokens) == 1 {
+			v[i].Tag = tag
+			return nil
+		}
+		return setTag(v[i].Value, tokens[1:], tag)
+	}
+	return fmt.Errorf("%q is not inside of an object or array", tokens[0])
+}
@@ -0,0 +1,66 @@
+package gojson
+
+import (
+	"encoding/json"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyJSONConversion(t *testing.T) {
+	Convey("Converting between gojson and plain JSON", t, func() {
+		src := `{
+			"name": "Joe" ` + "`\"max-length\": 4`" + `,
+			"a/b~c": 1 ` + "`\"number\": < 400`" + `,
+			"sister": {"name": "Jessy" ` + "`\"unique\": true`" + `, "colors": ["red", "blue" ` + "`\"editable\": false`" + `]},
+			"empty": {},
+			"list": []
+		}`
+		m, _, err := ParseAsArrayOrSlice(src)
+		So(err, ShouldBeNil)
+
+		Convey("ToJSON should return JSON readable by encoding/json", func() {
+			data, sidecar, err := ToJSON(m)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"a/b~c":1,"empty":{},"list":[],"name":"Joe","sister":{"colors":["red","blue"],"name":"Jessy"}}`)
+			So(string(sidecar), ShouldEqual, `{"/a~1b~0c":"\"number\": < 400","/name":"\"max-length\": 4",`+
+				`"/sister/colors/1":"\"editable\": false","/sister/name":"\"unique\": true"}`)
+
+			var v map[string]interface{}
+			So(json.Unmarshal(data, &v), ShouldBeNil)
+			So(v["name"], ShouldEqual, "Joe")
+			var tags map[string]string
+			So(json.Unmarshal(sidecar, &tags), ShouldBeNil)
+			So(tags["/sister/name"], ShouldEqual, `"unique": true`)
+		})
+
+		Convey("FromJSON should reattach tags", func() {
+			data, sidecar, _ := ToJSON(m)
+			back, arr, err := FromJSON(data, sidecar)
+			So(err, ShouldBeNil)
+			So(arr, ShouldBeNil)
+			So(back, ShouldResemble, m)
+		})
+
+		Convey("FromJSON should read output of encoding/json", func() {
+			data, _ := json.MarshalIndent([]interface{}{1, "x", map[string]interface{}{"k": []int{}}}, " ", "  ")
+			_, arr, err := FromJSON(data, []byte(`{"/0": "\"number\": > 0"}`))
+			So(err, ShouldBeNil)
+			So(len(arr), ShouldEqual, 3)
+			So(arr[0], ShouldResemble, Node{Value: 1, Tag: `"number": > 0`})
+		})
+
+		Convey("FromJSON should fail on pointers to missing nodes", func() {
+			data, _, _ := ToJSON(m)
+			for _, sidecar := range []string{`{"/missing": ""}`, `{"/list/0": ""}`, `{"name": ""}`, `{"/name": 1}`, `{"": "x"}`} {
+				_, _, err := FromJSON(data, []byte(sidecar))
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("StripTags should drop every tag", func() {
+			data, err := StripTags([]Node{Node{Value: "red", Tag: `"editable": false`}})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `["red"]`)
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package gojson
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"reflect"
+	"unicode"
+)
+
+// Marshaler is implemented by types which can encode themselves into a
+// gojson Node, including its tag.
+type Marshaler interface {
+	MarshalGoJSON() (Node, error)
+}
+
+// Unmarshaler is implemented by types which can decode themselves from a
+// gojson Node. UnmarshalGoJSON receives the node with its tag.
+type Unmarshaler interface {
+	UnmarshalGoJSON(Node) error
+}
+
+// MarshalGoJSON returns the node itself.
+func (n Node) MarshalGoJSON() (Node, error) {
+	return n, nil
+}
+
+// UnmarshalGoJSON stores a copy of node, so struct fields of type Node
+// keep the source value and tag as they are.
+func (n *Node) UnmarshalGoJSON(node Node) error {
+	*n = node
+	return nil
+}
+
+// Marshal returns the gojson encoding of v. It works like encoding/json
+// Marshal: json struct tags name the keys, nil pointers, slices and maps
+// become null and []byte becomes a base64 string. The rest of a struct
+// field tag is written as the gojson tag of the value, as SerializeStruct
+// does. Node, map[string]Node, *OrderedMap and []Node are written as they are.
+func Marshal(v interface{}) ([]byte, error) {
+	return marshal(v, serializeConfig{Trim: true})
+}
+
+// MarshalIndent is like Marshal but begins every nested element on a new
+// line starting with prefix followed by copies of indent according to the
+// nesting depth.
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return marshal(v, serializeConfig{Prefix: prefix, Indent: indent})
+}
+
+func marshal(v interface{}, c serializeConfig) ([]byte, error) {
+	node, err := getNode(v, reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := serializeNode(&b, node, c, 0); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal parses gojson data and stores the result in the value pointed
+// to by v, which may be of any type, like encoding/json Unmarshal does.
+// Unlike ParseToStruct, data may also be a single string, number or bool
+// and object keys match struct fields case-insensitively when there is no
+// exact match. Like encoding/json, strings should be quoted and nothing but
+// whitespace may follow the value, otherwise a *SyntaxError is returned.
+// Empty interfaces receive map[string]interface{}, []interface{}, float64,
+// string, bool or nil, tags are dropped.
+//
+// Unmarshal decodes with DecoderOptions{CaseInsensitiveKeys: true}. Strict
+// decoding and TagSink need other options, which ParseToStructWithOptions
+// and Decoder.SetDecoderOptions take.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("gojson: Unmarshal target should be a non-nil pointer")
+	}
+	root, err := parseValue(data)
+	if err != nil {
+		return err
+	}
+	return newDecodeState(DecoderOptions{CaseInsensitiveKeys: true}).decode(rv.Elem(), root)
+}
+
+// parseValue parses any gojson value including a bare string, number or
+// bool. Unlike ParseWithOptions, it rejects unquoted strings and anything
+// but whitespace after the value.
+func parseValue(data []byte) (interface{}, error) {
+	opts := ParseOptions{strict: true}
+	c := len(data) - len(bytes.TrimLeftFunc(data, unicode.IsSpace))
+	var root interface{}
+	var end int
+	var err error
+	switch {
+	case c == len(data) || data[c] == '{' || data[c] == '[':
+		root, end, err = parseBytes(data, opts)
+	case data[c] == '"':
+		var s []byte
+		s, end, err = scanString(data, c)
+		root, end = string(s), end+1
+	default:
+		end = len(data)
+		if i := bytes.IndexFunc(data[c:], unicode.IsSpace); i != -1 {
+			end = c + i
+		}
+		m := mapData{Value: data[c:end], Start: c}
+		if m.Type, err = detectType(data, end, &m, opts); err != nil {
+			return nil, err
+		}
+		var node Node
+		node, err = createValue(data, c, &m, opts)
+		root = node.Value
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rest := bytes.TrimLeftFunc(data[end:], unicode.IsSpace); len(rest) != 0 {
+		return nil, syntaxError(data, len(data)-len(rest), "end of input")
+	}
+	return root, nil
+}
+
+// marshalerNode returns the node of a Marshaler, encoding.TextMarshaler or
+// driver.Valuer. Text comes before Value, so types which also implement
+// driver.Valuer for a database keep their text form.
+func marshalerNode(v interface{}) (Node, bool, error) {
+	switch m := v.(type) {
+	case Marshaler:
+		node, err := m.MarshalGoJSON()
+		return node, true, err
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		return Node{Value: string(text)}, true, err
+	case driver.Valuer:
+		node, err := valuerNode(m)
+		return node, true, err
+	}
+	return Node{}, false, nil
+}
+
+// unmarshal decodes node into f if a pointer to f implements Unmarshaler,
+// encoding.TextUnmarshaler or sql.Scanner, in this order. It reports
+// whether it did.
+func unmarshal(f reflect.Value, node Node, path decodePath) (bool, error) {
+	if f.Kind() == reflect.Ptr || !f.CanAddr() {
+		return false, nil
+	}
+	switch u := f.Addr().Interface().(type) {
+	case Unmarshaler:
+		return true, u.UnmarshalGoJSON(node)
+	case encoding.TextUnmarshaler:
+		if node.Value == nil {
+			return true, nil
+		}
+		s, ok := node.Value.(string)
+		if !ok {
+			s, ok = numberLiteral(node.Value)
+		}
+		if !ok {
+			return true, &UnmarshalTypeError{Path: path.key, GoType: f.Type(), GoJSONType: valueKind(node.Value)}
+		}
+		return true, u.UnmarshalText([]byte(s))
+	case sql.Scanner:
+		return true, scan(u, f, node, path)
+	}
+	return false, nil
+}
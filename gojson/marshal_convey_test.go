@@ -0,0 +1,284 @@
+package gojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+type marshalPet struct {
+	Name  string  `json:"name" limit:"10"`
+	Age   uint8   `json:"age"`
+	Owner *string `json:"owner"`
+}
+
+type marshalHome struct {
+	Pets    []marshalPet      `json:"pets"`
+	Rooms   map[string]int    `json:"rooms"`
+	Color   marshalColor      `json:"color"`
+	Weight  float32           `json:"weight"`
+	Data    []byte            `json:"data"`
+	Extra   interface{}       `json:"extra"`
+	Partner *marshalPet       `json:"partner"`
+	Labels  map[string]string `json:"labels"`
+}
+
+type marshalColor string
+
+func TestConveyMarshal(t *testing.T) {
+	Convey("Marshal should encode any value", t, func() {
+		Convey("Primitives should be written as they are", func() {
+			for v, expected := range map[interface{}]string{
+				5: "5", "a\"b": `"a\"b"`, true: "true", marshalColor("red"): `"red"`, int8(-3): "-3", uint(7): "7",
+			} {
+				data, err := Marshal(v)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, expected)
+			}
+			data, err := Marshal(nil)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "null")
+		})
+
+		Convey("Structs should keep non-json tags as gojson tags", func() {
+			owner := "Joe"
+			home := &marshalHome{
+				Pets:   []marshalPet{{Name: "Rex", Age: 3, Owner: &owner}},
+				Rooms:  map[string]int{"kitchen": 1},
+				Color:  "red",
+				Weight: 0.1,
+				Data:   []byte("hi"),
+			}
+			data, err := Marshal(home)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"pets":[{"name":"Rex"`+"`limit:\"10\"`"+`,"age":3,"owner":"Joe"}],`+
+				`"rooms":{"kitchen":1},"color":"red","weight":0.1,"data":"aGk=","extra":null,"partner":null,"labels":null}`)
+		})
+
+		Convey("Floats and struct keys should be written as encoding/json does", func() {
+			for _, v := range []interface{}{123456789.0, 1e-7, -2.5e21, 1e20, float32(0.1), float32(3e-7), 0.0} {
+				expected, _ := json.Marshal(v)
+				data, err := Marshal(v)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, string(expected))
+			}
+			data, err := Marshal(struct{ Z, A, M int }{1, 2, 3})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"Z":1,"A":2,"M":3}`)
+		})
+
+		Convey("Arrays and nodes should be supported", func() {
+			data, err := Marshal([2]int{1, 2})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "[1,2]")
+			data, err = Marshal(Node{Value: []Node{{Value: "x", Tag: `"unique": true`}}})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `["x"`+"`\"unique\": true`"+`]`)
+		})
+
+		Convey("MarshalIndent should indent nested elements", func() {
+			data, err := MarshalIndent(map[string]interface{}{"a": []int{1}}, ">", "  ")
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "{\n>  \"a\": [\n>    1\n>  ]\n>}")
+		})
+	})
+}
+
+func TestConveyUnmarshal(t *testing.T) {
+	Convey("Unmarshal should decode into any target", t, func() {
+		Convey("Primitive targets should accept bare values", func() {
+			var i int
+			So(Unmarshal([]byte(" 42 "), &i), ShouldBeNil)
+			So(i, ShouldEqual, 42)
+			var s string
+			So(Unmarshal([]byte(`"a\nb"`), &s), ShouldBeNil)
+			So(s, ShouldEqual, "a\nb")
+			var f float64
+			So(Unmarshal([]byte("1.5"), &f), ShouldBeNil)
+			So(f, ShouldEqual, 1.5)
+			var b bool
+			So(Unmarshal([]byte("true"), &b), ShouldBeNil)
+			So(b, ShouldBeTrue)
+		})
+
+		Convey("Structs should be decoded the way encoding/json does", func() {
+			src := `{
+				"Pets": [{"NAME": "Rex" ` + "`\"max-length\": 10`" + `, "age": 3, "owner": "Joe"}],
+				"rooms": {"kitchen": 1},
+				"color": "red",
+				"weight": 2,
+				"data": "aGk=",
+				"extra": {"list": [1, "x", true]}
+			}`
+			home := marshalHome{}
+			So(Unmarshal([]byte(src), &home), ShouldBeNil)
+			So(len(home.Pets), ShouldEqual, 1)
+			So(home.Pets[0].Name, ShouldEqual, "Rex")
+			So(home.Pets[0].Age, ShouldEqual, 3)
+			So(*home.Pets[0].Owner, ShouldEqual, "Joe")
+			So(home.Rooms, ShouldResemble, map[string]int{"kitchen": 1})
+			So(home.Color, ShouldEqual, marshalColor("red"))
+			So(home.Weight, ShouldEqual, 2)
+			So(string(home.Data), ShouldEqual, "hi")
+			So(home.Extra, ShouldResemble, map[string]interface{}{"list": []interface{}{1.0, "x", true}})
+			So(home.Partner, ShouldBeNil)
+			So(Unmarshal([]byte("null"), &home.Rooms), ShouldBeNil)
+			So(home.Rooms, ShouldBeNil)
+		})
+
+		Convey("Results should match encoding/json", func() {
+			data := []byte(`{"pets": [{"name": "Rex", "age": 3}], "rooms": {"a": 1, "b": 2}, "extra": [1.5, "x"]}`)
+			var expected, actual marshalHome
+			So(json.Unmarshal(data, &expected), ShouldBeNil)
+			So(Unmarshal(data, &actual), ShouldBeNil)
+			So(actual, ShouldResemble, expected)
+
+			var m, jm map[string]interface{}
+			So(json.Unmarshal(data, &jm), ShouldBeNil)
+			So(Unmarshal(data, &m), ShouldBeNil)
+			So(m, ShouldResemble, jm)
+		})
+
+		Convey("Round trip through Marshal should keep the value", func() {
+			owner := "Ann"
+			home := marshalHome{
+				Pets:    []marshalPet{{Name: "Rex", Owner: &owner}},
+				Rooms:   map[string]int{},
+				Data:    []byte{1, 2},
+				Extra:   "x",
+				Partner: &marshalPet{Name: "Tom", Owner: &owner},
+				Labels:  map[string]string{"k": "v"},
+			}
+			data, err := Marshal(home)
+			So(err, ShouldBeNil)
+			var back marshalHome
+			So(Unmarshal(data, &back), ShouldBeNil)
+			So(back, ShouldResemble, home)
+		})
+
+		Convey("Wrong targets and mismatching values should return errors", func() {
+			var i int
+			So(Unmarshal([]byte("1"), i), ShouldNotBeNil)
+			So(Unmarshal([]byte("1"), nil), ShouldNotBeNil)
+			So(Unmarshal([]byte(`"x"`), &i), ShouldNotBeNil)
+			So(Unmarshal([]byte(`"x" 1`), new(string)), ShouldNotBeNil)
+			var pets []marshalPet
+			So(Unmarshal([]byte(`{"name": "Rex"}`), &pets), ShouldNotBeNil)
+		})
+
+		Convey("Malformed input should return SyntaxError", func() {
+			inputs := map[string]int{
+				"1 junk":      2,
+				"{} junk":     3,
+				"nullx":       0,
+				"[1] ]":       4,
+				"abc":         0,
+				`{"a": abc}`:  6,
+				`[1, tru]`:    4,
+				"\"x\" \"y\"": 4,
+			}
+			for input, offset := range inputs {
+				var v interface{}
+				err := Unmarshal([]byte(input), &v)
+				serr, ok := err.(*SyntaxError)
+				So(ok, ShouldBeTrue)
+				So(serr.Offset, ShouldEqual, offset)
+			}
+			var v interface{}
+			So(Unmarshal([]byte(" null \n"), &v), ShouldBeNil)
+			So(Unmarshal([]byte(`{"a": [1, true, null]} `), &v), ShouldBeNil)
+		})
+	})
+}
+
+type marshalMoney struct {
+	cents int64
+}
+
+func (m marshalMoney) MarshalGoJSON() (Node, error) {
+	return Node{Value: fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100), Tag: `"currency": "EUR"`}, nil
+}
+
+func (m *marshalMoney) UnmarshalGoJSON(node Node) error {
+	s, ok := node.Value.(string)
+	if !ok {
+		return errors.New("money should be a string")
+	}
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%d", &units, &cents); err != nil {
+		return err
+	}
+	m.cents = units*100 + cents
+	return nil
+}
+
+type marshalLevel int
+
+func (l marshalLevel) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[l]), nil
+}
+
+func (l *marshalLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+type marshalOrder struct {
+	Price  marshalMoney   `json:"price"`
+	Tip    *marshalMoney  `json:"tip"`
+	Levels []marshalLevel `json:"levels"`
+	Raw    Node           `json:"raw"`
+}
+
+func TestConveyMarshaler(t *testing.T) {
+	Convey("Custom encodings should be honoured", t, func() {
+		order := marshalOrder{
+			Price:  marshalMoney{1234},
+			Tip:    &marshalMoney{50},
+			Levels: []marshalLevel{1, 0},
+			Raw:    Node{Value: []Node{{Value: 1}}, Tag: `"editable": false`},
+		}
+		expected := `{"price":"12.34"` + "`\"currency\": \"EUR\"`" + `,"tip":"0.50"` + "`\"currency\": \"EUR\"`" +
+			`,"levels":["high","low"],"raw":[1]` + "`\"editable\": false`" + `}`
+
+		Convey("SerializeStruct and Marshal should use MarshalGoJSON and MarshalText", func() {
+			s, err := SerializeStruct(order, true)
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, expected)
+			data, err := Marshal(&order)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, expected)
+		})
+
+		Convey("Serialize should use them for node values", func() {
+			s, err := Serialize(map[string]Node{"p": {Value: marshalMoney{5}, Tag: `"unique": true`}, "l": {Value: marshalLevel(1)}}, true)
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, `{"l":"high","p":"0.05"`+"`\"unique\": true`"+`}`)
+		})
+
+		Convey("ParseToStruct and Unmarshal should use UnmarshalGoJSON and UnmarshalText", func() {
+			var parsed marshalOrder
+			So(ParseToStruct(&parsed, expected), ShouldBeNil)
+			So(parsed, ShouldResemble, order)
+			var back marshalOrder
+			So(Unmarshal([]byte(expected), &back), ShouldBeNil)
+			So(back, ShouldResemble, order)
+		})
+
+		Convey("Their errors should be returned", func() {
+			var parsed marshalOrder
+			So(Unmarshal([]byte(`{"price": 12}`), &parsed), ShouldNotBeNil)
+			So(Unmarshal([]byte(`{"levels": ["medium"]}`), &parsed), ShouldNotBeNil)
+			So(Unmarshal([]byte(`{"levels": [1]}`), &parsed), ShouldNotBeNil)
+		})
+	})
+}
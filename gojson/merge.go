@@ -0,0 +1,213 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ArrayMergePolicy tells Merge what to do with an array of the overlay
+// which meets an array of the base.
+type ArrayMergePolicy int
+
+const (
+	// ReplaceArrays takes the overlay array, as RFC 7386 does.
+	ReplaceArrays ArrayMergePolicy = iota
+	// AppendArrays appends the elements of the overlay array to the base one.
+	AppendArrays
+	// MergeArraysByKey merges objects of the overlay array into the objects
+	// of the base array having the same value of MergeOptions.ArrayKey and
+	// appends the rest of the elements.
+	MergeArraysByKey
+)
+
+// TagMergePolicy tells Merge which tag a value present in both the base
+// and the overlay gets.
+type TagMergePolicy int
+
+const (
+	// OverlayTags takes the tag of the overlay, or the one of the base if
+	// the overlay value has no tag.
+	OverlayTags TagMergePolicy = iota
+	// UnionTags takes the keys of both tags, overlay values win for keys
+	// present in both.
+	UnionTags
+	// BaseTags takes the tag of the base, or the one of the overlay if the
+	// base value has no tag.
+	BaseTags
+)
+
+// MergeOptions are the policies of Merge. The zero value is RFC 7386.
+type MergeOptions struct {
+	Arrays   ArrayMergePolicy
+	ArrayKey string // key identifying objects of arrays for MergeArraysByKey
+	Tags     TagMergePolicy
+}
+
+// Merge merges overlay into base, both map[string]Node, *OrderedMap,
+// []Node or Node, by JSON Merge Patch (RFC 7386) rules and returns the
+// result, leaving base and overlay as they are. Objects are merged key by
+// key, null of the overlay removes the key and any other overlay value
+// replaces the base one. Arrays and tags are merged by the policies of
+// opts. Keys of a *OrderedMap base keep their order, new keys follow.
+//
+// Layered configuration is the merge of its layers one after another:
+//
+//	config, err := Merge(defaults, environment, opts)
+//	config, err = Merge(config, overrides, opts)
+func Merge(base, overlay interface{}, opts MergeOptions) (interface{}, error) {
+	if opts.Arrays == MergeArraysByKey && opts.ArrayKey == "" {
+		return nil, errors.New("gojson: MergeArraysByKey needs MergeOptions.ArrayKey")
+	}
+	node, err := mergeNodes(rootNode(base), rootNode(overlay), "", opts)
+	return node.Value, err
+}
+
+func mergeNodes(base, overlay Node, path string, opts MergeOptions) (Node, error) {
+	tag, err := mergeTags(base.Tag, overlay.Tag, opts.Tags)
+	if err != nil {
+		return Node{}, fmt.Errorf("gojson: can't merge tags of %q: %s", path, err)
+	}
+	result := Node{Tag: tag}
+	overlayNodes, ok := objectNodes(overlay.Value)
+	if !ok {
+		result.Value = copyValue(overlay.Value)
+		if baseItems, ok := base.Value.([]Node); ok {
+			if overlayItems, ok := overlay.Value.([]Node); ok {
+				result.Value, err = mergeArrays(baseItems, overlayItems, path, opts)
+			}
+		}
+		return result, err
+	}
+	merged := NewOrderedMap()
+	baseNodes, _ := objectNodes(base.Value)
+	for _, key := range objectKeys(base.Value) {
+		if node, ok := overlayNodes[key]; ok && node.Value == nil {
+			continue
+		}
+		node := baseNodes[key]
+		node.Value = copyValue(node.Value)
+		merged.Set(key, node)
+	}
+	for _, key := range objectKeys(overlay.Value) {
+		node := overlayNodes[key]
+		if node.Value == nil {
+			continue
+		}
+		if old, ok := merged.Get(key); ok {
+			if node, err = mergeNodes(old, node, joinPath(path, key), opts); err != nil {
+				return Node{}, err
+			}
+		} else {
+			node = removeNulls(node)
+		}
+		merged.Set(key, node)
+	}
+	if _, ordered := base.Value.(*OrderedMap); ordered {
+		result.Value = merged
+	} else {
+		result.Value = merged.values
+	}
+	return result, nil
+}
+
+// removeNulls returns a copy of an overlay value which doesn't meet a base
+// value: nulls of its objects mean nothing to remove, so they are dropped.
+func removeNulls(node Node) Node {
+	nodes, ok := objectNodes(node.Value)
+	if !ok {
+		node.Value = copyValue(node.Value)
+		return node
+	}
+	result := NewOrderedMap()
+	for _, key := range objectKeys(node.Value) {
+		if nodes[key].Value != nil {
+			result.Set(key, removeNulls(nodes[key]))
+		}
+	}
+	if _, ordered := node.Value.(*OrderedMap); ordered {
+		node.Value = result
+	} else {
+		node.Value = result.values
+	}
+	return node
+}
+
+func mergeArrays(base, overlay []Node, path string, opts MergeOptions) ([]Node, error) {
+	switch opts.Arrays {
+	case AppendArrays:
+		return copyValue(append(append([]Node{}, base...), overlay...)).([]Node), nil
+	case MergeArraysByKey:
+		result := copyValue(base).([]Node)
+		for _, item := range overlay {
+			i := indexByKey(result, item, opts.ArrayKey)
+			if i == -1 {
+				result = append(result, removeNulls(item))
+				continue
+			}
+			merged, err := mergeNodes(result[i], item, indexPath(path, i), opts)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = merged
+		}
+		return result, nil
+	}
+	return copyValue(overlay).([]Node), nil
+}
+
+// indexByKey returns the index of the object of items with the same value
+// of the key as item has, -1 if there is none.
+func indexByKey(items []Node, item Node, key string) int {
+	nodes, ok := objectNodes(item.Value)
+	if !ok {
+		return -1
+	}
+	id, ok := nodes[key]
+	if !ok {
+		return -1
+	}
+	for i, other := range items {
+		otherNodes, ok := objectNodes(other.Value)
+		if !ok {
+			continue
+		}
+		if otherID, ok := otherNodes[key]; ok && valuesEqual(otherID.Value, id.Value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeTags(base, overlay string, policy TagMergePolicy) (string, error) {
+	switch {
+	case base == "":
+		return overlay, nil
+	case overlay == "":
+		return base, nil
+	case policy == BaseTags:
+		return base, nil
+	case policy != UnionTags:
+		return overlay, nil
+	}
+	baseTags, err := ParseTag(base)
+	if err != nil {
+		return "", err
+	}
+	overlayTags, err := ParseTag(overlay)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range overlayTags {
+		replaced := false
+		for i := range baseTags {
+			if baseTags[i].Key == entry.Key {
+				baseTags[i] = entry
+				replaced = true
+			}
+		}
+		if !replaced {
+			baseTags = append(baseTags, entry)
+		}
+	}
+	return baseTags.String(), nil
+}
@@ -0,0 +1,89 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyMerge(t *testing.T) {
+	parse := func(src string) interface{} {
+		root, err := ParseWithOptions(src, ParseOptions{OrderedMaps: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	serialize := func(root interface{}, err error) string {
+		So(err, ShouldBeNil)
+		re, err := Serialize(root, true)
+		So(err, ShouldBeNil)
+		return re
+	}
+
+	Convey("Merge", t, func() {
+		defaults := parse(`{
+			"title": "Goodbye!" ` + "`\"max-length\": 20`" + `,
+			"author": {"givenName": "John", "familyName": "Doe"},
+			"tags": ["example", "sample"],
+			"servers": [{"id": 1, "host": "a"}, {"id": 2, "host": "b"} ` + "`\"editable\": false`" + `],
+			"content": "This will be unchanged"
+		}`)
+		overlay := parse(`{
+			"title": "Hello!" ` + "`\"min-length\": 1, \"max-length\": 10`" + `,
+			"phoneNumber": "+01-123-456-7890",
+			"author": {"familyName": null, "nick": {"short": "J", "long": null}},
+			"tags": ["example"],
+			"servers": [{"id": 2, "host": "c"} ` + "`\"unique\": true`" + `, {"id": 3, "host": null}]
+		}`)
+
+		Convey("Should follow RFC 7386 by default", func() {
+			So(serialize(Merge(defaults, overlay, MergeOptions{})), ShouldEqual, `{"title":"Hello!"`+
+				"`\"min-length\": 1, \"max-length\": 10`"+`,"author":{"givenName":"John","nick":{"short":"J"}},`+
+				`"tags":["example"],"servers":[{"id":2,"host":"c"}`+"`\"unique\": true`"+`,{"id":3,"host":null}],`+
+				`"content":"This will be unchanged","phoneNumber":"+01-123-456-7890"}`)
+
+			So(serialize(Merge(map[string]Node{"a": {Value: "b"}}, []Node{{Value: "c"}}, MergeOptions{})),
+				ShouldEqual, `["c"]`)
+			So(serialize(Merge([]Node{{Value: "c"}}, map[string]Node{"a": {Value: "b"}, "b": {}}, MergeOptions{})),
+				ShouldEqual, `{"a":"b"}`)
+		})
+
+		Convey("Should append arrays or merge them by key", func() {
+			re := serialize(Merge(defaults, overlay, MergeOptions{Arrays: AppendArrays}))
+			So(re, ShouldContainSubstring, `"tags":["example","sample","example"]`)
+
+			re = serialize(Merge(defaults, overlay, MergeOptions{Arrays: MergeArraysByKey, ArrayKey: "id"}))
+			So(re, ShouldContainSubstring, `"tags":["example","sample","example"]`)
+			So(re, ShouldContainSubstring, `"servers":[{"id":1,"host":"a"},{"id":2,"host":"c"}`+
+				"`\"unique\": true`"+`,{"id":3}]`)
+
+			_, err := Merge(defaults, overlay, MergeOptions{Arrays: MergeArraysByKey})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should merge tags by the policy", func() {
+			root, err := Merge(defaults, overlay, MergeOptions{Arrays: MergeArraysByKey, ArrayKey: "id", Tags: UnionTags})
+			So(err, ShouldBeNil)
+			title, _ := Get(root, "title")
+			So(title.Tag, ShouldEqual, `"max-length": 10, "min-length": 1`)
+			server, _ := Get(root, "servers[1]")
+			So(server.Tag, ShouldEqual, `"editable": false, "unique": true`)
+
+			root, _ = Merge(defaults, overlay, MergeOptions{Arrays: MergeArraysByKey, ArrayKey: "id", Tags: BaseTags})
+			title, _ = Get(root, "title")
+			So(title.Tag, ShouldEqual, `"max-length": 20`)
+			server, _ = Get(root, "servers[1]")
+			So(server.Tag, ShouldEqual, `"editable": false`)
+
+			_, err = Merge(map[string]Node{"a": {Tag: "a:1"}}, map[string]Node{"a": {Value: 1, Tag: "(("}},
+				MergeOptions{Tags: UnionTags})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should leave its arguments as they are", func() {
+			before := serialize(defaults, nil)
+			Merge(defaults, overlay, MergeOptions{Arrays: MergeArraysByKey, ArrayKey: "id"})
+			So(serialize(defaults, nil), ShouldEqual, before)
+		})
+	})
+}
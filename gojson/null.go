@@ -0,0 +1,63 @@
+package gojson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"reflect"
+)
+
+// Kind is the gojson type of a node value.
+type Kind string
+
+const (
+	NullKind   Kind = "null"
+	BoolKind   Kind = "bool"
+	NumberKind Kind = "number"
+	StringKind Kind = "string"
+	ArrayKind  Kind = "array"
+	ObjectKind Kind = "object"
+)
+
+// Kind returns the gojson type of the node value, the one it is serialized
+// as: time.Time, time.Duration and TextMarshaler values are strings, named
+// types go by their underlying kind. Null values, nested or not, are nodes
+// with nil Value and may carry a tag like any other value.
+func (n Node) Kind() Kind {
+	return Kind(valueKind(n.Value))
+}
+
+// IsNull reports whether the node value is null.
+func (n Node) IsNull() bool {
+	return n.Value == nil
+}
+
+// valuerNode returns the node of a driver.Valuer, like sql.NullString.
+// Invalid sql.Null* values become null.
+func valuerNode(v driver.Valuer) (Node, error) {
+	value, err := v.Value()
+	if b, ok := value.([]byte); ok {
+		return Node{Value: base64.StdEncoding.EncodeToString(b)}, err
+	}
+	return Node{Value: value}, err
+}
+
+// scan stores the node value into s, like sql.NullInt64, which is set
+// invalid by null.
+func scan(s sql.Scanner, f reflect.Value, node Node, path decodePath) error {
+	var value interface{}
+	switch v := node.Value.(type) {
+	case nil, string, bool, float64:
+		value = v
+	case int:
+		value = int64(v)
+	case Number:
+		value = string(v)
+	default:
+		return &UnmarshalTypeError{Path: path.key, GoType: f.Type(), GoJSONType: valueKind(node.Value)}
+	}
+	if err := s.Scan(value); err != nil {
+		return &UnmarshalTypeError{Path: path.key, GoType: f.Type(), GoJSONType: valueKind(node.Value)}
+	}
+	return nil
+}
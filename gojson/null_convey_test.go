@@ -0,0 +1,152 @@
+package gojson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestConveyNull(t *testing.T) {
+	Convey("Nested null", t, func() {
+		src := `{"name": null ` + "`\"required\": true`" + `, "sister": {"name": null}, "colors": [null, "red", null]}`
+
+		Convey("Should be parsed as nil with the null kind", func() {
+			m, _, err := ParseAsArrayOrSlice(src)
+			So(err, ShouldBeNil)
+			So(m["name"], ShouldResemble, Node{Tag: `"required": true`})
+			So(m["name"].Kind(), ShouldEqual, NullKind)
+			So(m["name"].IsNull(), ShouldBeTrue)
+			So(m["sister"].Value.(map[string]Node)["name"].Value, ShouldBeNil)
+			So(m["colors"].Value, ShouldResemble, []Node{{}, {Value: "red"}, {}})
+			So(m["colors"].Kind(), ShouldEqual, ArrayKind)
+			So(Node{Value: "null"}.Kind(), ShouldEqual, StringKind)
+		})
+
+		Convey("Kind should match the way values are written", func() {
+			type name string
+			type flag bool
+			var none *int
+			one := 1
+			kinds := map[Kind][]interface{}{
+				StringKind: {time.Now(), time.Second, name("Joe"), priority(1), []byte("x")},
+				BoolKind:   {flag(true)},
+				NumberKind: {uint8(1), float32(1.5), Number("1"), &one},
+				ObjectKind: {struct{ A int }{1}, map[string]int{}},
+				ArrayKind:  {[]int{1}, [2]string{}},
+				NullKind:   {none},
+			}
+			for kind, values := range kinds {
+				for _, v := range values {
+					So(Node{Value: v}.Kind(), ShouldEqual, kind)
+				}
+			}
+		})
+
+		Convey("Should be serialized back with its tag", func() {
+			m, _, _ := ParseAsArrayOrSlice(src)
+			re, err := Serialize(m, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"colors":[null,"red",null],"name":null`+"`\"required\": true`"+`,"sister":{"name":null}}`)
+
+			errs := Validate(m)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, "name")
+		})
+
+		Convey("Should set pointers, slices and maps to nil", func() {
+			name := "Joe"
+			v := struct {
+				Name   *string           `json:"name"`
+				Colors []*string         `json:"colors"`
+				Sister map[string]string `json:"sister"`
+				Age    int               `json:"age"`
+			}{Name: &name, Sister: map[string]string{}, Age: 42}
+			So(ParseToStruct(&v, `{"name": null, "colors": [null, "red"], "sister": null, "age": null}`), ShouldBeNil)
+			So(v.Name, ShouldBeNil)
+			So(len(v.Colors), ShouldEqual, 2)
+			So(v.Colors[0], ShouldBeNil)
+			So(*v.Colors[1], ShouldEqual, "red")
+			So(v.Sister, ShouldBeNil)
+			So(v.Age, ShouldEqual, 42)
+		})
+	})
+
+	Convey("sql.Null types", t, func() {
+		type row struct {
+			Name  sql.NullString  `json:"name"`
+			Age   sql.NullInt64   `json:"age" min:"0"`
+			Score sql.NullFloat64 `json:"score"`
+			Admin sql.NullBool    `json:"admin"`
+		}
+
+		Convey("Should be parsed from values and null", func() {
+			v := row{Name: sql.NullString{String: "Joe", Valid: true}}
+			So(ParseToStruct(&v, `{"name": null, "age": 42, "score": 1.5, "admin": true}`), ShouldBeNil)
+			So(v.Name.Valid, ShouldBeFalse)
+			So(v.Age, ShouldResemble, sql.NullInt64{Int64: 42, Valid: true})
+			So(v.Score, ShouldResemble, sql.NullFloat64{Float64: 1.5, Valid: true})
+			So(v.Admin, ShouldResemble, sql.NullBool{Bool: true, Valid: true})
+
+			err := ParseToStruct(&v, `{"age": "many"}`)
+			So(err, ShouldHaveSameTypeAs, &UnmarshalTypeError{})
+			So(err.(*UnmarshalTypeError).Path, ShouldEqual, "age")
+		})
+
+		Convey("Should be serialized as values or null", func() {
+			v := row{Name: sql.NullString{String: "Joe", Valid: true}}
+			re, err := SerializeStruct(v, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"name":"Joe","age":null`+"`min:\"0\"`"+`,"score":null,"admin":null}`)
+		})
+
+		Convey("Should not hide the text form of types which also have one", func() {
+			type task struct {
+				L priority `json:"l"`
+			}
+			re, err := SerializeStruct(task{L: 1}, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"l":"high"}`)
+
+			var v task
+			So(Unmarshal([]byte(`{"l": "high"}`), &v), ShouldBeNil)
+			So(v.L, ShouldEqual, 1)
+			So(ParseToStruct(&v, `{"l": "low"}`), ShouldBeNil)
+			So(v.L, ShouldEqual, 0)
+		})
+	})
+}
+
+// priority is an enum stored as a number in a database and written as text.
+type priority int
+
+func (p priority) Value() (driver.Value, error) {
+	return int64(p), nil
+}
+
+func (p *priority) Scan(src interface{}) error {
+	n, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("can't scan %T into priority", src)
+	}
+	*p = priority(n)
+	return nil
+}
+
+func (p priority) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[p]), nil
+}
+
+func (p *priority) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*p = 0
+	case "high":
+		*p = 1
+	default:
+		return fmt.Errorf("unknown priority %q", text)
+	}
+	return nil
+}
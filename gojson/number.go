@@ -0,0 +1,150 @@
+package gojson
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// Number is the literal of a gojson number. The parser produces it for
+// every number with ParseOptions.UseNumber and, not to lose precision,
+// for integers which don't fit into int. Serialize writes it as it is.
+type Number string
+
+var numberType = reflect.TypeOf(Number(""))
+
+// String returns the literal of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// BigInt returns the number as a big.Int of any size.
+func (n Number) BigInt() (*big.Int, error) {
+	i, ok := new(big.Int).SetString(string(n), 10)
+	if !ok {
+		return nil, fmt.Errorf("gojson: number %s is not an integer", string(n))
+	}
+	return i, nil
+}
+
+// isNumber reports whether s is a number literal by the JSON grammar.
+func isNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		if i+1 == len(s) || !isDigit(s[i+1]) {
+			return false
+		}
+		i = skipDigits(s, i+1)
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if i == len(s) || !isDigit(s[i]) {
+			return false
+		}
+		i = skipDigits(s, i)
+	}
+	return i == len(s)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// formatFloat writes a float the way encoding/json does: without exponent
+// unless it is below 1e-6 or at least 1e21, and with e-7 rather than e-07.
+func formatFloat(f float64, bits int) string {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	s := strconv.FormatFloat(f, format, -1, bits)
+	if n := len(s); format == 'e' && n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+		s = s[:n-2] + s[n-1:]
+	}
+	return s
+}
+
+// numberLiteral returns the literal of a parsed number value.
+func numberLiteral(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case Number:
+		return string(v), true
+	}
+	return "", false
+}
+
+// setNumber stores a parsed number value into f of any numeric kind or of
+// type Number. It reports false if the value isn't a number or doesn't fit.
+func setNumber(f reflect.Value, v interface{}) bool {
+	n, ok := numberLiteral(v)
+	if !ok {
+		return false
+	}
+	if f.Type() == numberType {
+		f.SetString(n)
+		return true
+	}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil || f.OverflowInt(i) {
+			return false
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(n, 10, 64)
+		if err != nil || f.OverflowUint(u) {
+			return false
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(n, f.Type().Bits())
+		if err != nil {
+			return false
+		}
+		f.SetFloat(x)
+	default:
+		return false
+	}
+	return true
+}
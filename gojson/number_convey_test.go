@@ -0,0 +1,105 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestConveyNumbers(t *testing.T) {
+	Convey("Parsing numbers", t, func() {
+		Convey("Should keep integers exact and floats as floats", func() {
+			m, _, err := ParseAsArrayOrSlice(`{"a": 9007199254740993, "b": 1.0, "c": -2e3, "d": 18446744073709551615, "e": 01}`)
+			So(err, ShouldBeNil)
+			So(m["a"].Value, ShouldEqual, 9007199254740993)
+			So(m["b"].Value, ShouldEqual, 1.0)
+			So(m["c"].Value, ShouldEqual, -2000.0)
+			So(m["d"].Value, ShouldEqual, Number("18446744073709551615"))
+			So(m["e"].Value, ShouldEqual, "01")
+		})
+
+		Convey("UseNumber should return every number as Number", func() {
+			arr, err := ParseWithOptions(`[1, 1.5, 1e400, "1"]`, ParseOptions{UseNumber: true})
+			So(err, ShouldBeNil)
+			So(arr, ShouldResemble, []Node{{Value: Number("1")}, {Value: Number("1.5")}, {Value: Number("1e400")}, {Value: "1"}})
+
+			dec := NewDecoder(strings.NewReader(`{"a": 2.50}`))
+			dec.UseNumber()
+			var v struct {
+				A interface{} `json:"a"`
+			}
+			So(dec.Decode(&v), ShouldBeNil)
+			So(v.A, ShouldEqual, Number("2.50"))
+		})
+
+		Convey("Numbers should be validated as numbers", func() {
+			root, _ := ParseWithOptions(`{"a": 500 `+"`\"number\": < 400`"+`, "b": 1.0 `+"`\"list\": [1]`"+`}`, ParseOptions{UseNumber: true})
+			errs := Validate(root)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, "a")
+		})
+
+		Convey("Number accessors should convert the literal", func() {
+			i, err := Number("-42").Int64()
+			So(err, ShouldBeNil)
+			So(i, ShouldEqual, -42)
+			f, err := Number("2.5").Float64()
+			So(err, ShouldBeNil)
+			So(f, ShouldEqual, 2.5)
+			b, err := Number("123456789012345678901234567890").BigInt()
+			So(err, ShouldBeNil)
+			So(b.String(), ShouldEqual, "123456789012345678901234567890")
+			_, err = Number("1.5").BigInt()
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Storing numbers into Go values", t, func() {
+		type numbers struct {
+			I8  int8     `json:"i8"`
+			U8  uint8    `json:"u8"`
+			I64 int64    `json:"i64"`
+			U64 uint64   `json:"u64"`
+			F32 float32  `json:"f32"`
+			N   Number   `json:"n"`
+			Big *big.Int `json:"big"`
+		}
+		src := `{"i8": -128, "u8": 255, "i64": 9007199254740993, "u64": 18446744073709551615,
+			"f32": 1.5, "n": 1.0, "big": 123456789012345678901234567890}`
+
+		Convey("Should work for any numeric kind", func() {
+			v := numbers{}
+			So(ParseToStruct(&v, src), ShouldBeNil)
+			So(v.I8, ShouldEqual, -128)
+			So(v.U8, ShouldEqual, 255)
+			So(v.I64, ShouldEqual, 9007199254740993)
+			So(v.U64, ShouldEqual, uint64(18446744073709551615))
+			So(v.F32, ShouldEqual, 1.5)
+			So(v.N, ShouldEqual, Number("1"))
+			So(v.Big.String(), ShouldEqual, "123456789012345678901234567890")
+		})
+
+		Convey("Should check overflow", func() {
+			for _, src := range []string{`{"i8": 128}`, `{"u8": -1}`, `{"u8": 256}`, `{"i64": 1.5}`, `{"f32": 1e39}`} {
+				err := ParseToStruct(&numbers{}, src)
+				So(err, ShouldHaveSameTypeAs, &UnmarshalTypeError{})
+				So(err.(*UnmarshalTypeError).GoJSONType, ShouldEqual, "number")
+			}
+		})
+
+		Convey("Should round trip through Marshal", func() {
+			v := numbers{}
+			ParseToStruct(&v, src)
+			data, err := Marshal(v)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"i8":-128,"u8":255,"i64":9007199254740993,"u64":18446744073709551615,`+
+				`"f32":1.5,"n":1,"big":123456789012345678901234567890}`)
+		})
+
+		Convey("Invalid Number should not be serialized", func() {
+			_, err := Serialize([]Node{{Value: Number("1x")}}, true)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package gojson
+
+import "sort"
+
+// OrderedMap is a JSON object which keeps its keys in insertion order. It
+// may be used as Node value everywhere map[string]Node is accepted and the
+// parser produces it when asked with ParseOptions.OrderedMaps.
+type OrderedMap struct {
+	keys   []string
+	values map[string]Node
+}
+
+// NewOrderedMap returns an empty OrderedMap.
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: map[string]Node{}}
+}
+
+// Set sets the node of the key. New keys are added to the end, existing
+// ones keep their position.
+func (om *OrderedMap) Set(key string, node Node) {
+	if om.values == nil {
+		om.values = map[string]Node{}
+	}
+	if _, exist := om.values[key]; !exist {
+		om.keys = append(om.keys, key)
+	}
+	om.values[key] = node
+}
+
+// Get returns the node of the key.
+func (om *OrderedMap) Get(key string) (Node, bool) {
+	node, exist := om.values[key]
+	return node, exist
+}
+
+// Delete removes the key.
+func (om *OrderedMap) Delete(key string) {
+	if _, exist := om.values[key]; !exist {
+		return
+	}
+	delete(om.values, key)
+	for i, k := range om.keys {
+		if k == key {
+			om.keys = append(om.keys[:i], om.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in order.
+func (om *OrderedMap) Keys() []string {
+	return append([]string{}, om.keys...)
+}
+
+// Len returns the number of keys.
+func (om *OrderedMap) Len() int {
+	return len(om.keys)
+}
+
+// Map returns the nodes as an ordinary map.
+func (om *OrderedMap) Map() map[string]Node {
+	m := make(map[string]Node, len(om.values))
+	for key, node := range om.values {
+		m[key] = node
+	}
+	return m
+}
+
+// objectNodes returns nodes of map[string]Node or *OrderedMap value.
+func objectNodes(v interface{}) (map[string]Node, bool) {
+	switch v := v.(type) {
+	case map[string]Node:
+		return v, true
+	case *OrderedMap:
+		return v.values, true
+	}
+	return nil, false
+}
+
+// objectKeys returns keys of map[string]Node sorted and keys of
+// *OrderedMap in their order.
+func objectKeys(v interface{}) []string {
+	switch v := v.(type) {
+	case map[string]Node:
+		return sortedKeys(v)
+	case *OrderedMap:
+		return v.Keys()
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]Node) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
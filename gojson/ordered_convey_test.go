@@ -0,0 +1,84 @@
+package gojson
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+)
+
+func TestConveyOrderedMap(t *testing.T) {
+	Convey("Using OrderedMap", t, func() {
+		om := NewOrderedMap()
+		om.Set("name", Node{Value: "John"})
+		om.Set("id", Node{Value: 5})
+		om.Set("colors", Node{Value: []Node{}})
+		om.Set("name", Node{Value: "Joe", Tag: `"editable": false`})
+
+		Convey("Should keep keys in insertion order", func() {
+			So(om.Keys(), ShouldResemble, []string{"name", "id", "colors"})
+			So(om.Len(), ShouldEqual, 3)
+			node, ok := om.Get("name")
+			So(ok, ShouldBeTrue)
+			So(node.Value, ShouldEqual, "Joe")
+		})
+
+		Convey("Should delete keys", func() {
+			om.Delete("id")
+			om.Delete("missing")
+			So(om.Keys(), ShouldResemble, []string{"name", "colors"})
+			_, ok := om.Get("id")
+			So(ok, ShouldBeFalse)
+			So(len(om.Map()), ShouldEqual, 2)
+		})
+	})
+
+	Convey("Parsing and serializing with key order", t, func() {
+		src := `{"zeta": 1, "alpha": {"y": "a", "b": "c"} ` + "`\"editable\": false`" + `, "mid": ["x", {"k2": true, "k1": false}]}`
+
+		Convey("Should keep source order when asked", func() {
+			root, err := ParseWithOptions(src, ParseOptions{OrderedMaps: true})
+			So(err, ShouldBeNil)
+			om, ok := root.(*OrderedMap)
+			So(ok, ShouldBeTrue)
+			So(om.Keys(), ShouldResemble, []string{"zeta", "alpha", "mid"})
+			r, err := Serialize(om, true)
+			So(err, ShouldBeNil)
+			So(r, ShouldEqual, `{"zeta":1,"alpha":{"y":"a","b":"c"}`+"`\"editable\": false`"+`,"mid":["x",{"k2":true,"k1":false}]}`)
+		})
+
+		Convey("Should sort keys when asked", func() {
+			root, _ := ParseWithOptions(src, ParseOptions{OrderedMaps: true})
+			var b bytes.Buffer
+			enc := NewEncoder(&b)
+			enc.SetSortKeys(true)
+			So(enc.Encode(root), ShouldBeNil)
+			So(b.String(), ShouldEqual, `{"alpha":{"b":"c","y":"a"}`+"`\"editable\": false`"+`,"mid":["x",{"k1":false,"k2":true}],"zeta":1}`+"\n")
+		})
+
+		Convey("Should always sort keys of map[string]Node", func() {
+			m, _, _ := ParseAsArrayOrSlice(src)
+			first, _ := Serialize(m, false)
+			for i := 0; i < 10; i++ {
+				r, _ := Serialize(m, false)
+				So(r, ShouldEqual, first)
+			}
+			So(strings.Index(first, "alpha"), ShouldBeLessThan, strings.Index(first, "zeta"))
+		})
+
+		Convey("Should be available from Decoder", func() {
+			dec := NewDecoder(strings.NewReader(src))
+			dec.SetParseOptions(ParseOptions{OrderedMaps: true})
+			root, err := dec.DecodeValue()
+			So(err, ShouldBeNil)
+			So(root.(*OrderedMap).Keys(), ShouldResemble, []string{"zeta", "alpha", "mid"})
+		})
+
+		Convey("Should be validated like map[string]Node", func() {
+			root, _ := ParseWithOptions(`{"b": "long" `+"`\"max-length\": 2`"+`, "a": "x"}`, ParseOptions{OrderedMaps: true})
+			errs := Validate(root)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, "b")
+		})
+	})
+}
@@ -0,0 +1,409 @@
+package gojson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single operation of a JSON Patch (RFC 6902) with the
+// gojson extension operations "settag" and "removetag".
+type Operation struct {
+	Op    string // "add", "remove", "replace", "move", "copy", "test", "settag" or "removetag"
+	Path  string // JSON Pointer of the target like "/sister/colors/1"
+	From  string // JSON Pointer of the source of "move" and "copy"
+	Value Node   // value of "add", "replace" and "test" with its tag
+	Tag   string // tag of "settag"
+}
+
+// Patch is a list of operations applied one after another.
+type Patch []Operation
+
+// ParsePatch parses a patch from gojson text like
+//
+//	[{"op": "replace", "path": "/name", "value": "Jim" `"max-length": 4`},
+//	 {"op": "settag", "path": "/sister", "tag": "\"editable\": false"}]
+//
+// Tags of "value" are the tags of the values the operations store.
+func ParsePatch(gojson string) (Patch, error) {
+	_, arr, err := ParseAsArrayOrSlice(gojson)
+	if err != nil {
+		return nil, err
+	}
+	if arr == nil {
+		return nil, fmt.Errorf("gojson: patch should be an array")
+	}
+	patch := make(Patch, len(arr))
+	for i, item := range arr {
+		nodes, ok := objectNodes(item.Value)
+		if !ok {
+			return nil, fmt.Errorf("gojson: patch operation %d should be an object", i)
+		}
+		op := &patch[i]
+		for key, target := range map[string]*string{"op": &op.Op, "path": &op.Path, "from": &op.From, "tag": &op.Tag} {
+			node, exist := nodes[key]
+			if !exist {
+				continue
+			}
+			if *target, ok = node.Value.(string); !ok {
+				return nil, fmt.Errorf("gojson: %q of patch operation %d should be a string", key, i)
+			}
+		}
+		if _, exist := nodes["path"]; !exist {
+			return nil, fmt.Errorf("gojson: patch operation %d has no \"path\"", i)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value, ok = nodes["value"]; !ok {
+				return nil, fmt.Errorf("gojson: %s operation %d has no \"value\"", op.Op, i)
+			}
+		case "move", "copy":
+			if _, exist := nodes["from"]; !exist {
+				return nil, fmt.Errorf("gojson: %s operation %d has no \"from\"", op.Op, i)
+			}
+		case "settag":
+			if _, exist := nodes["tag"]; !exist {
+				return nil, fmt.Errorf("gojson: settag operation %d has no \"tag\"", i)
+			}
+		case "remove", "removetag":
+		default:
+			return nil, fmt.Errorf("gojson: unknown op %q of patch operation %d", op.Op, i)
+		}
+	}
+	return patch, nil
+}
+
+// Serialize returns the gojson text of the patch, the one ParsePatch reads.
+func (p Patch) Serialize(trim bool) (string, error) {
+	items := make([]Node, len(p))
+	for i, op := range p {
+		fields := NewOrderedMap()
+		fields.Set("op", Node{Value: op.Op})
+		if op.From != "" || op.Op == "move" || op.Op == "copy" {
+			fields.Set("from", Node{Value: op.From})
+		}
+		fields.Set("path", Node{Value: op.Path})
+		switch op.Op {
+		case "add", "replace", "test":
+			fields.Set("value", op.Value)
+		case "settag":
+			fields.Set("tag", Node{Value: op.Tag})
+		}
+		items[i] = Node{Value: fields}
+	}
+	return Serialize(items, trim)
+}
+
+// ApplyPatch applies the patch to a copy of root, which is map[string]Node,
+// *OrderedMap, []Node or Node, and returns the patched value. Root is left
+// as it is, as well as the whole result if any operation fails.
+//
+// Operations follow RFC 6902 and keep tags: "replace", and "add" of an
+// existing object key, keep the tag of the replaced node unless the value
+// has a tag of its own, "move" and "copy" take the tag along and "test"
+// with a tagged value also compares tags. "settag" replaces the tag of the
+// node and "removetag" removes it.
+func ApplyPatch(root interface{}, patch Patch) (interface{}, error) {
+	node := rootNode(root)
+	node.Value = copyValue(node.Value)
+	for i, op := range patch {
+		var err error
+		if node, err = applyOperation(node, op); err != nil {
+			return nil, fmt.Errorf("gojson: patch operation %d (%s %s): %s", i, op.Op, op.Path, err)
+		}
+	}
+	return node.Value, nil
+}
+
+func applyOperation(root Node, op Operation) (Node, error) {
+	tokens, err := splitPointer(op.Path)
+	if err != nil {
+		return root, err
+	}
+	value := op.Value
+	value.Value = copyValue(value.Value)
+	switch op.Op {
+	case "add":
+		return addNode(root, tokens, value)
+	case "remove":
+		root, _, err = removeNode(root, tokens)
+		return root, err
+	case "replace":
+		return replaceNode(root, tokens, value)
+	case "move", "copy":
+		from, err := splitPointer(op.From)
+		if err != nil {
+			return root, err
+		}
+		var node Node
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return root, fmt.Errorf("can't move %q into itself", op.From)
+			}
+			root, node, err = removeNode(root, from)
+		} else {
+			node, err = pointerNode(root, from)
+			node.Value = copyValue(node.Value)
+		}
+		if err != nil {
+			return root, err
+		}
+		return addNode(root, tokens, node)
+	case "test":
+		node, err := pointerNode(root, tokens)
+		if err != nil {
+			return root, err
+		}
+		if !valuesEqual(node.Value, op.Value.Value) || op.Value.Tag != "" && !sameTags(node.Tag, op.Value.Tag) {
+			return root, fmt.Errorf("test failed")
+		}
+		return root, nil
+	case "settag", "removetag":
+		segments, err := pointerSegments(root, tokens)
+		if err != nil {
+			return root, err
+		}
+		return editNode(root, segments, false, func(node Node) (Node, error) {
+			node.Tag = op.Tag
+			if op.Op == "removetag" {
+				node.Tag = ""
+			}
+			return node, nil
+		})
+	}
+	return root, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// pointerSegments converts JSON Pointer tokens into path segments of the
+// existing nodes of root.
+func pointerSegments(root Node, tokens []string) ([]pathSegment, error) {
+	segments := make([]pathSegment, len(tokens))
+	node := root
+	pointer := ""
+	for i, token := range tokens {
+		pointer = joinPointer(pointer, token)
+		segment, err := pointerSegment(node, token, false)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if node, ok = segment.child(node); !ok {
+			return nil, fmt.Errorf("no value at %q", pointer)
+		}
+		segments[i] = segment
+	}
+	return segments, nil
+}
+
+// pointerSegment converts a JSON Pointer token into the segment of a key
+// or an index of node. The "-" token is the index past the end of an array
+// if end is set.
+func pointerSegment(node Node, token string, end bool) (pathSegment, error) {
+	if _, ok := objectNodes(node.Value); ok {
+		return pathSegment{kind: segmentKey, key: token}, nil
+	}
+	items, ok := node.Value.([]Node)
+	if !ok {
+		return pathSegment{}, fmt.Errorf("%q is not inside of an object or array", token)
+	}
+	if token == "-" && end {
+		return pathSegment{kind: segmentIndex, index: len(items)}, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) {
+		return pathSegment{}, fmt.Errorf("invalid array index %q", token)
+	}
+	return pathSegment{kind: segmentIndex, index: i}, nil
+}
+
+func pointerNode(root Node, tokens []string) (Node, error) {
+	segments, err := pointerSegments(root, tokens)
+	if err != nil {
+		return Node{}, err
+	}
+	for _, segment := range segments {
+		root, _ = segment.child(root)
+	}
+	return root, nil
+}
+
+// addNode puts node at tokens. Elements of arrays are inserted, keys of
+// objects are added or replaced keeping their tags unless node has one.
+func addNode(root Node, tokens []string, node Node) (Node, error) {
+	if len(tokens) == 0 {
+		if node.Tag == "" {
+			node.Tag = root.Tag
+		}
+		return node, nil
+	}
+	segments, err := pointerSegments(root, tokens[:len(tokens)-1])
+	if err != nil {
+		return root, err
+	}
+	return editNode(root, segments, false, func(parent Node) (Node, error) {
+		segment, err := pointerSegment(parent, tokens[len(tokens)-1], true)
+		if err != nil {
+			return parent, err
+		}
+		if segment.kind == segmentKey {
+			if old, ok := segment.child(parent); ok && node.Tag == "" {
+				node.Tag = old.Tag
+			}
+			return setChild(parent, segment, node)
+		}
+		items := parent.Value.([]Node)
+		if segment.index > len(items) {
+			return parent, fmt.Errorf("index %d is out of range", segment.index)
+		}
+		result := make([]Node, 0, len(items)+1)
+		result = append(result, items[:segment.index]...)
+		result = append(result, node)
+		parent.Value = append(result, items[segment.index:]...)
+		return parent, nil
+	})
+}
+
+// replaceNode replaces the existing node at tokens keeping its tag unless
+// node has one.
+func replaceNode(root Node, tokens []string, node Node) (Node, error) {
+	segments, err := pointerSegments(root, tokens)
+	if err != nil {
+		return root, err
+	}
+	if len(segments) == 0 {
+		return addNode(root, tokens, node)
+	}
+	last := segments[len(segments)-1]
+	return editNode(root, segments[:len(segments)-1], false, func(parent Node) (Node, error) {
+		if old, _ := last.child(parent); node.Tag == "" {
+			node.Tag = old.Tag
+		}
+		return setChild(parent, last, node)
+	})
+}
+
+// removeNode removes the node at tokens and returns it.
+func removeNode(root Node, tokens []string) (Node, Node, error) {
+	if len(tokens) == 0 {
+		return root, Node{}, fmt.Errorf("can't remove the root")
+	}
+	segments, err := pointerSegments(root, tokens)
+	if err != nil {
+		return root, Node{}, err
+	}
+	last := segments[len(segments)-1]
+	var removed Node
+	root, err = editNode(root, segments[:len(segments)-1], false, func(parent Node) (Node, error) {
+		removed, _ = last.child(parent)
+		switch v := parent.Value.(type) {
+		case map[string]Node:
+			delete(v, last.key)
+		case *OrderedMap:
+			v.Delete(last.key)
+		case []Node:
+			parent.Value = append(append([]Node{}, v[:last.index]...), v[last.index+1:]...)
+		}
+		return parent, nil
+	})
+	return root, removed, err
+}
+
+// copyValue returns a deep copy of objects and arrays, other values are
+// returned as they are.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]Node:
+		m := make(map[string]Node, len(v))
+		for key, node := range v {
+			node.Value = copyValue(node.Value)
+			m[key] = node
+		}
+		return m
+	case *OrderedMap:
+		om := NewOrderedMap()
+		for _, key := range v.Keys() {
+			node := v.values[key]
+			node.Value = copyValue(node.Value)
+			om.Set(key, node)
+		}
+		return om
+	case []Node:
+		items := make([]Node, len(v))
+		for i, node := range v {
+			node.Value = copyValue(node.Value)
+			items[i] = node
+		}
+		return items
+	}
+	return value
+}
+
+// CreatePatch returns a patch which turns a into b, see Diff. Changes of
+// values are "add", "remove" and "replace" operations, changes of tags are
+// "settag" and "removetag" ones.
+func CreatePatch(a, b interface{}) Patch {
+	changes := Diff(a, b)
+	patch := Patch{}
+	for i := 0; i < len(changes); i++ {
+		c := changes[i]
+		pointer := pathPointer(c.Path)
+		switch c.Kind {
+		case ValueAdded:
+			patch = append(patch, Operation{Op: "add", Path: pointer, Value: c.To})
+		case ValueRemoved:
+			// elements removed from the end of an array are removed from
+			// the last one, so indexes of the rest stay valid
+			run := i + 1
+			for run < len(changes) && changes[run].Kind == ValueRemoved && isNextIndex(changes[run-1].Path, changes[run].Path) {
+				run++
+			}
+			for j := run - 1; j >= i; j-- {
+				patch = append(patch, Operation{Op: "remove", Path: pathPointer(changes[j].Path)})
+			}
+			i = run - 1
+		case ValueChanged:
+			patch = append(patch, Operation{Op: "replace", Path: pointer, Value: Node{Value: c.To.Value}})
+		default:
+			// changes of the keys of a tag come together, the whole new
+			// tag replaces the old one at once
+			for i+1 < len(changes) && changes[i+1].Path == c.Path && isTagChange(changes[i+1].Kind) {
+				i++
+			}
+			if c.To.Tag == "" {
+				patch = append(patch, Operation{Op: "removetag", Path: pointer})
+			} else {
+				patch = append(patch, Operation{Op: "settag", Path: pointer, Tag: c.To.Tag})
+			}
+		}
+	}
+	return patch
+}
+
+func isTagChange(kind ChangeKind) bool {
+	return kind == TagAdded || kind == TagRemoved || kind == TagChanged
+}
+
+// pathPointer converts a path like "sister.colors[1]" into a JSON Pointer.
+func pathPointer(path string) string {
+	segments, _ := parseNodePath(path)
+	pointer := ""
+	for _, segment := range segments {
+		if segment.kind == segmentKey {
+			pointer = joinPointer(pointer, segment.key)
+		} else {
+			pointer = joinPointer(pointer, strconv.Itoa(segment.index))
+		}
+	}
+	return pointer
+}
+
+// isNextIndex reports whether path b is the element of an array following
+// the one of path a.
+func isNextIndex(a, b string) bool {
+	i := strings.LastIndexByte(a, '[')
+	if i == -1 || !strings.HasPrefix(b, a[:i+1]) {
+		return false
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(a[i+1:], "]"))
+	return err == nil && b == indexPath(a[:i], n+1)
+}
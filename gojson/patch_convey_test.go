@@ -0,0 +1,115 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyPatch(t *testing.T) {
+	src := `{"name": "Joe" ` + "`\"max-length\": 4`" + `, "sister": {"name": "Jessy", "colors": ["red", "blue"]} ` +
+		"`\"editable\": false`" + `, "a/b": 1}`
+
+	Convey("ApplyPatch", t, func() {
+		root, _, _ := ParseAsArrayOrSlice(src)
+
+		Convey("Should apply RFC 6902 and tag operations", func() {
+			patch, err := ParsePatch(`[
+				{"op": "test", "path": "/name", "value": "Joe"},
+				{"op": "replace", "path": "/name", "value": "Jim"},
+				{"op": "add", "path": "/sister/colors/1", "value": "green" ` + "`\"unique\": true`" + `},
+				{"op": "add", "path": "/sister/colors/-", "value": "dark"},
+				{"op": "remove", "path": "/sister/colors/0"},
+				{"op": "copy", "from": "/sister", "path": "/brother"},
+				{"op": "move", "from": "/a~1b", "path": "/brother/age"},
+				{"op": "removetag", "path": "/sister"},
+				{"op": "settag", "path": "/brother/name", "tag": "\"required\": true"},
+				{"op": "test", "path": "/brother", "value": {"name": "Jessy", "colors": ["green", "blue", "dark"], "age": 1} ` +
+				"`\"editable\": false`" + `}
+			]`)
+			So(err, ShouldBeNil)
+			result, err := ApplyPatch(root, patch)
+			So(err, ShouldBeNil)
+			re, _ := Serialize(result, true)
+			So(re, ShouldEqual, `{"brother":{"age":1,"colors":["green"`+"`\"unique\": true`"+`,"blue","dark"],`+
+				`"name":"Jessy"`+"`\"required\": true`"+`}`+"`\"editable\": false`"+`,"name":"Jim"`+"`\"max-length\": 4`"+
+				`,"sister":{"colors":["green"`+"`\"unique\": true`"+`,"blue","dark"],"name":"Jessy"}}`)
+
+			original, _ := Serialize(root, true)
+			expected, _ := Serialize(func() map[string]Node { m, _, _ := ParseAsArrayOrSlice(src); return m }(), true)
+			So(original, ShouldEqual, expected)
+		})
+
+		Convey("Should fail as a whole", func() {
+			for _, patch := range []Patch{
+				{{Op: "test", Path: "/name", Value: Node{Value: "Joe", Tag: `"max-length": 5`}}},
+				{{Op: "replace", Path: "/missing", Value: Node{Value: 1}}},
+				{{Op: "add", Path: "/sister/colors/3", Value: Node{Value: 1}}},
+				{{Op: "add", Path: "/sister/colors/01", Value: Node{Value: 1}}},
+				{{Op: "remove", Path: ""}},
+				{{Op: "move", From: "/sister", Path: "/sister/brother"}},
+				{{Op: "settag", Path: "name", Tag: "a:1"}},
+				{{Op: "merge", Path: "/name"}},
+			} {
+				_, err := ApplyPatch(root, patch)
+				So(err, ShouldNotBeNil)
+			}
+			_, err := ApplyPatch(root, Patch{{Op: "remove", Path: "/name"}, {Op: "remove", Path: "/name"}})
+			So(err.Error(), ShouldEqual, `gojson: patch operation 1 (remove /name): no value at "/name"`)
+			So(root["name"].Value, ShouldEqual, "Joe")
+		})
+
+		Convey("ParsePatch should check operations", func() {
+			for _, src := range []string{`{}`, `[1]`, `[{"op": "add", "path": "/a"}]`, `[{"op": "add", "value": 1}]`,
+				`[{"op": "move", "path": "/a"}]`, `[{"op": "settag", "path": "/a"}]`, `[{"op": "x", "path": "/a"}]`,
+				`[{"op": 1, "path": "/a"}]`} {
+				_, err := ParsePatch(src)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+
+	Convey("CreatePatch", t, func() {
+		a, _, _ := ParseAsArrayOrSlice(src)
+		b, _, _ := ParseAsArrayOrSlice(`{"name": "Joe", "sister": {"name": "Jessy" ` + "`\"unique\": true`" +
+			`, "colors": ["red"]} ` + "`\"editable\": true`" + `, "a/b": [1, 2, 3]}`)
+
+		Convey("Should turn one tree into the other", func() {
+			patch := CreatePatch(a, b)
+			re, err := patch.Serialize(true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `[{"op":"replace","path":"/a~1b","value":[1,2,3]},{"op":"removetag","path":"/name"},`+
+				`{"op":"settag","path":"/sister","tag":"\"editable\": true"},{"op":"remove","path":"/sister/colors/1"},`+
+				`{"op":"settag","path":"/sister/name","tag":"\"unique\": true"}]`)
+
+			parsed, err := ParsePatch(re)
+			So(err, ShouldBeNil)
+			result, err := ApplyPatch(a, parsed)
+			So(err, ShouldBeNil)
+			So(Diff(result, b), ShouldBeEmpty)
+			So(len(Diff(a, b)), ShouldEqual, 5)
+		})
+
+		Convey("Should remove trailing elements from the end", func() {
+			x := []Node{{Value: 1}, {Value: 2}, {Value: 3}, {Value: 4}}
+			y := []Node{{Value: 1}, {Value: 5}}
+			patch := CreatePatch(x, y)
+			So(patch, ShouldResemble, Patch{
+				{Op: "replace", Path: "/1", Value: Node{Value: 5}},
+				{Op: "remove", Path: "/3"},
+				{Op: "remove", Path: "/2"},
+			})
+			result, err := ApplyPatch(x, patch)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, y)
+		})
+
+		Convey("Should set a tag changed in several keys once", func() {
+			x := map[string]Node{"name": {Value: "Joe", Tag: `"editable": false, "limit": 4`}}
+			y := map[string]Node{"name": {Value: "Joe", Tag: `"limit": 5, "unique": true`}}
+			So(len(Diff(x, y)), ShouldEqual, 3)
+			So(CreatePatch(x, y), ShouldResemble, Patch{
+				{Op: "settag", Path: "/name", Tag: `"limit": 5, "unique": true`},
+			})
+		})
+	})
+}
@@ -0,0 +1,60 @@
+package gojson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// joinPath appends an object key to a path like "sister.colors[1]". Keys
+// which can't be written after a dot are put into quoted brackets, so Get
+// reads every path back.
+func joinPath(path, key string) string {
+	if !isPathIdent(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isPathIdent reports whether key can be written after a dot in a path.
+func isPathIdent(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isPathIdentByte(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexPath appends an array index to a path.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// joinPointer appends a reference token to a JSON Pointer (RFC 6901).
+func joinPointer(pointer, token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	return pointer + "/" + strings.Replace(token, "/", "~1", -1)
+}
+
+// splitPointer returns unescaped reference tokens of a JSON Pointer.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("gojson: JSON pointer %q should start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.Replace(token, "~1", "/", -1)
+		tokens[i] = strings.Replace(token, "~0", "~", -1)
+	}
+	return tokens, nil
+}
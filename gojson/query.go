@@ -0,0 +1,672 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotFound is returned by Get when there is no value at the path.
+var ErrNotFound = errors.New("gojson: no value at the path")
+
+// Match is a node found by Query together with its path like
+// "sister.colors[1]". The path of the root is empty.
+type Match struct {
+	Path string
+	Node Node
+}
+
+// Get returns the node at the path like "sister.colors[1]" or
+// `friends[0]["first name"]` inside of root, which is map[string]Node,
+// *OrderedMap, []Node or Node. The path may start with "$" and negative
+// indexes count from the end of an array. Paths of Match and
+// ValidationError are accepted as they are.
+func Get(root interface{}, path string) (Node, error) {
+	segments, err := parseNodePath(path)
+	if err != nil {
+		return Node{}, err
+	}
+	node := rootNode(root)
+	for _, segment := range segments {
+		var ok bool
+		if node, ok = segment.child(node); !ok {
+			return Node{}, ErrNotFound
+		}
+	}
+	return node, nil
+}
+
+// Query returns the nodes of root selected by a JSONPath expression in
+// the document order. Supported are:
+//
+//	$                    the root, may be omitted
+//	.name, ["name"]      a key of an object, single quotes work as well
+//	[1], [-1]            an element of an array, negative counts from the end
+//	[1:3], [:-1]         a slice of an array
+//	.*, [*]              all children of an object or an array
+//	..name, ..[*]        a selector applied to the node and all its descendants
+//	[?(expr)]            children for which expr holds
+//
+// Filter expressions compare @ paths like @.id or @["first name"] with
+// numbers, 'strings', true, false, null or other @ paths using ==, !=,
+// <, <=, >, >= and =~ (which matches a regular expression). A bare @ path
+// tests that the value exists. @#key, like @.sister#editable, is the value
+// of the key of the node tag. Expressions are combined with &&, || and !
+// and grouped with parentheses:
+//
+//	$.friends[?(@.id > 0 && @.name =~ '^J')].name
+//	$..[?(@#editable == false)]
+func Query(root interface{}, query string) ([]Match, error) {
+	segments, err := parsePath(query)
+	if err != nil {
+		return nil, err
+	}
+	matches := []Match{{Node: rootNode(root)}}
+	for _, segment := range segments {
+		next := []Match{}
+		for _, m := range matches {
+			next = segment.apply(m, next)
+		}
+		matches = next
+	}
+	return matches, nil
+}
+
+func rootNode(root interface{}) Node {
+	if node, ok := root.(Node); ok {
+		return node
+	}
+	return Node{Value: root}
+}
+
+// children returns the members of an object or the elements of an array.
+func children(m Match) []Match {
+	if nodes, ok := objectNodes(m.Node.Value); ok {
+		result := make([]Match, 0, len(nodes))
+		for _, key := range objectKeys(m.Node.Value) {
+			result = append(result, Match{joinPath(m.Path, key), nodes[key]})
+		}
+		return result
+	}
+	items, _ := m.Node.Value.([]Node)
+	result := make([]Match, len(items))
+	for i, item := range items {
+		result[i] = Match{indexPath(m.Path, i), item}
+	}
+	return result
+}
+
+// descendants appends m and all nodes inside of it to result.
+func descendants(m Match, result []Match) []Match {
+	result = append(result, m)
+	for _, child := range children(m) {
+		result = descendants(child, result)
+	}
+	return result
+}
+
+type segmentKind int
+
+const (
+	segmentKey segmentKind = iota
+	segmentIndex
+	segmentWildcard
+	segmentSlice
+	segmentFilter
+	segmentDescend
+)
+
+// pathSegment is a single selector of a path or a query.
+type pathSegment struct {
+	kind   segmentKind
+	key    string
+	index  int
+	start  *int
+	end    *int
+	filter filterExpr
+}
+
+// child returns the node selected by a key or an index segment.
+func (s pathSegment) child(node Node) (Node, bool) {
+	if s.kind == segmentKey {
+		nodes, ok := objectNodes(node.Value)
+		if !ok {
+			return Node{}, false
+		}
+		child, ok := nodes[s.key]
+		return child, ok
+	}
+	items, ok := node.Value.([]Node)
+	if !ok {
+		return Node{}, false
+	}
+	i := arrayIndex(s.index, len(items))
+	if i < 0 || i >= len(items) {
+		return Node{}, false
+	}
+	return items[i], true
+}
+
+// apply appends the matches the segment selects from m to result.
+func (s pathSegment) apply(m Match, result []Match) []Match {
+	switch s.kind {
+	case segmentKey:
+		if node, ok := s.child(m.Node); ok {
+			result = append(result, Match{joinPath(m.Path, s.key), node})
+		}
+	case segmentIndex:
+		if node, ok := s.child(m.Node); ok {
+			i := arrayIndex(s.index, len(m.Node.Value.([]Node)))
+			result = append(result, Match{indexPath(m.Path, i), node})
+		}
+	case segmentWildcard:
+		result = append(result, children(m)...)
+	case segmentSlice:
+		items, ok := m.Node.Value.([]Node)
+		if !ok {
+			break
+		}
+		start, end := sliceBound(s.start, 0, len(items)), sliceBound(s.end, len(items), len(items))
+		for i := start; i < end; i++ {
+			result = append(result, Match{indexPath(m.Path, i), items[i]})
+		}
+	case segmentFilter:
+		for _, child := range children(m) {
+			if s.filter.eval(child.Node) {
+				result = append(result, child)
+			}
+		}
+	case segmentDescend:
+		result = descendants(m, result)
+	}
+	return result
+}
+
+// sliceBound returns the bound of a slice of an array of length n.
+func sliceBound(bound *int, def, n int) int {
+	if bound == nil {
+		return def
+	}
+	i := *bound
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+// filterExpr is a parsed filter expression of a query.
+type filterExpr interface {
+	eval(node Node) bool
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) eval(node Node) bool { return e.left.eval(node) || e.right.eval(node) }
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) eval(node Node) bool { return e.left.eval(node) && e.right.eval(node) }
+
+type notExpr struct{ expr filterExpr }
+
+func (e notExpr) eval(node Node) bool { return !e.expr.eval(node) }
+
+type existsExpr struct{ operand operand }
+
+func (e existsExpr) eval(node Node) bool {
+	_, ok := e.operand.value(node)
+	return ok
+}
+
+type compareExpr struct {
+	left, right operand
+	op          string
+	re          *regexp.Regexp
+}
+
+func (e compareExpr) eval(node Node) bool {
+	a, ok := e.left.value(node)
+	if !ok {
+		return false
+	}
+	if e.re != nil {
+		s, ok := a.(string)
+		return ok && e.re.MatchString(s)
+	}
+	b, ok := e.right.value(node)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "==":
+		return valuesEqual(a, b)
+	case "!=":
+		return !valuesEqual(a, b)
+	}
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && compareFloat(fa, TagOp(e.op), fb)
+	}
+	sa, ok := a.(string)
+	sb, ok2 := b.(string)
+	if !ok || !ok2 {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return sa < sb
+	case "<=":
+		return sa <= sb
+	case ">":
+		return sa > sb
+	}
+	return sa >= sb
+}
+
+// operand is either a literal or a path relative to the filtered node,
+// possibly followed by a key of the tag of the node at the path.
+type operand struct {
+	relative bool
+	path     []pathSegment
+	tagKey   string
+	hasTag   bool
+	literal  interface{}
+}
+
+func (o operand) value(node Node) (interface{}, bool) {
+	if !o.relative {
+		return o.literal, true
+	}
+	for _, segment := range o.path {
+		var ok bool
+		if node, ok = segment.child(node); !ok {
+			return nil, false
+		}
+	}
+	if !o.hasTag {
+		return node.Value, true
+	}
+	tags, err := node.Tags()
+	if err != nil {
+		return nil, false
+	}
+	entry, ok := tags.Get(o.tagKey)
+	return entry.Value, ok
+}
+
+// parseNodePath parses a path of a single node, made only of keys and
+// indexes.
+func parseNodePath(path string) ([]pathSegment, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments {
+		if segment.kind != segmentKey && segment.kind != segmentIndex {
+			return nil, fmt.Errorf("gojson: path %q should hold only keys and indexes, use Query", path)
+		}
+	}
+	return segments, nil
+}
+
+type pathParser struct {
+	s string
+	i int
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	p := &pathParser{s: path}
+	var segments []pathSegment
+	if p.peek('$') {
+		p.i++
+	} else if !p.end() && isPathIdentByte(p.s[p.i]) {
+		segments = append(segments, pathSegment{kind: segmentKey, key: p.parseIdent()})
+	}
+	rest, err := p.parseSegments(true)
+	if err != nil {
+		return nil, err
+	}
+	if !p.end() {
+		return nil, p.syntaxError("'.' or '['")
+	}
+	return append(segments, rest...), nil
+}
+
+func (p *pathParser) end() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *pathParser) peek(c byte) bool {
+	return p.i < len(p.s) && p.s[p.i] == c
+}
+
+func (p *pathParser) skipSpace() {
+	for p.i < len(p.s) && isTagSpace(p.s[p.i]) {
+		p.i++
+	}
+}
+
+func (p *pathParser) syntaxError(expected string) error {
+	found := "end of path"
+	if !p.end() {
+		r, _ := utf8.DecodeRuneInString(p.s[p.i:])
+		found = strconv.QuoteRune(r)
+	}
+	return fmt.Errorf("gojson: invalid path %q: unexpected %s at offset %d, expected %s", p.s, found, p.i, expected)
+}
+
+// parseSegments parses selectors up to the first character which can't
+// start one. Only keys and indexes are allowed unless all is set.
+func (p *pathParser) parseSegments(all bool) ([]pathSegment, error) {
+	segments := []pathSegment{}
+	for !p.end() {
+		switch p.s[p.i] {
+		case '.':
+			p.i++
+			if p.peek('.') {
+				if !all {
+					return nil, p.syntaxError("key")
+				}
+				p.i++
+				segments = append(segments, pathSegment{kind: segmentDescend})
+				if p.peek('[') {
+					continue
+				}
+			}
+			if p.peek('*') && all {
+				p.i++
+				segments = append(segments, pathSegment{kind: segmentWildcard})
+				continue
+			}
+			key := p.parseIdent()
+			if key == "" {
+				return nil, p.syntaxError("key")
+			}
+			segments = append(segments, pathSegment{kind: segmentKey, key: key})
+		case '[':
+			segment, err := p.parseBracket(all)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+		default:
+			return segments, nil
+		}
+	}
+	return segments, nil
+}
+
+func (p *pathParser) parseBracket(all bool) (pathSegment, error) {
+	p.i++
+	p.skipSpace()
+	var segment pathSegment
+	switch {
+	case p.peek('\'') || p.peek('"'):
+		key, err := p.parseQuoted()
+		if err != nil {
+			return segment, err
+		}
+		segment = pathSegment{kind: segmentKey, key: key}
+	case p.peek('*') && all:
+		p.i++
+		segment = pathSegment{kind: segmentWildcard}
+	case p.peek('?') && all:
+		p.i++
+		if !p.peek('(') {
+			return segment, p.syntaxError("'('")
+		}
+		p.i++
+		filter, err := p.parseOr()
+		if err != nil {
+			return segment, err
+		}
+		p.skipSpace()
+		if !p.peek(')') {
+			return segment, p.syntaxError("')'")
+		}
+		p.i++
+		segment = pathSegment{kind: segmentFilter, filter: filter}
+	default:
+		start, err := p.parseIndex()
+		if err != nil {
+			return segment, err
+		}
+		p.skipSpace()
+		if !p.peek(':') || !all {
+			if start == nil {
+				return segment, p.syntaxError("index")
+			}
+			segment = pathSegment{kind: segmentIndex, index: *start}
+			break
+		}
+		p.i++
+		p.skipSpace()
+		end, err := p.parseIndex()
+		if err != nil {
+			return segment, err
+		}
+		segment = pathSegment{kind: segmentSlice, start: start, end: end}
+	}
+	p.skipSpace()
+	if !p.peek(']') {
+		return segment, p.syntaxError("']'")
+	}
+	p.i++
+	return segment, nil
+}
+
+// parseIndex parses an optional integer.
+func (p *pathParser) parseIndex() (*int, error) {
+	start := p.i
+	if p.peek('-') {
+		p.i++
+	}
+	for !p.end() && isDigit(p.s[p.i]) {
+		p.i++
+	}
+	if p.i == start {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(p.s[start:p.i])
+	if err != nil {
+		p.i = start
+		return nil, p.syntaxError("index")
+	}
+	return &i, nil
+}
+
+func (p *pathParser) parseIdent() string {
+	start := p.i
+	for !p.end() && isPathIdentByte(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+func isPathIdentByte(c byte) bool {
+	return c == '_' || c == '-' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+// parseQuoted parses a string in single or double quotes. Escapes of
+// double quoted strings are the Go ones, single quoted strings may only
+// escape a quote and a backslash.
+func (p *pathParser) parseQuoted() (string, error) {
+	quote := p.s[p.i]
+	start := p.i
+	for p.i++; !p.end() && p.s[p.i] != quote; p.i++ {
+		if p.s[p.i] == '\\' {
+			p.i++
+		}
+	}
+	if p.end() {
+		return "", p.syntaxError(string(quote))
+	}
+	p.i++
+	if quote == '"' {
+		s, err := strconv.Unquote(p.s[start:p.i])
+		if err != nil {
+			p.i = start
+			return "", p.syntaxError("string")
+		}
+		return s, nil
+	}
+	s := p.s[start+1 : p.i-1]
+	s = strings.Replace(s, `\'`, `'`, -1)
+	return strings.Replace(s, `\\`, `\`, -1), nil
+}
+
+func (p *pathParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); strings.HasPrefix(p.s[p.i:], "||"); p.skipSpace() {
+		p.i += 2
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *pathParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); strings.HasPrefix(p.s[p.i:], "&&"); p.skipSpace() {
+		p.i += 2
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *pathParser) parseUnary() (filterExpr, error) {
+	p.skipSpace()
+	switch {
+	case p.peek('!'):
+		p.i++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	case p.peek('('):
+		p.i++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.peek(')') {
+			return nil, p.syntaxError("')'")
+		}
+		p.i++
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+var compareOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+func (p *pathParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := ""
+	for _, candidate := range compareOps {
+		if strings.HasPrefix(p.s[p.i:], candidate) {
+			op = candidate
+			p.i += len(op)
+			break
+		}
+	}
+	if op == "" {
+		if !left.relative {
+			return nil, p.syntaxError("comparison operator")
+		}
+		return existsExpr{left}, nil
+	}
+	p.skipSpace()
+	start := p.i
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	expr := compareExpr{left: left, right: right, op: op}
+	if op == "=~" {
+		pattern, ok := right.literal.(string)
+		if right.relative || !ok {
+			p.i = start
+			return nil, p.syntaxError("regular expression string")
+		}
+		if expr.re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("gojson: invalid path %q: %s", p.s, err)
+		}
+	}
+	return expr, nil
+}
+
+func (p *pathParser) parseOperand() (operand, error) {
+	switch {
+	case p.peek('@'):
+		p.i++
+		path, err := p.parseSegments(false)
+		if err != nil {
+			return operand{}, err
+		}
+		o := operand{relative: true, path: path}
+		if p.peek('#') {
+			p.i++
+			if p.peek('\'') || p.peek('"') {
+				o.tagKey, err = p.parseQuoted()
+			} else if o.tagKey = p.parseIdent(); o.tagKey == "" {
+				err = p.syntaxError("tag key")
+			}
+			o.hasTag = true
+		}
+		return o, err
+	case p.peek('\'') || p.peek('"'):
+		s, err := p.parseQuoted()
+		return operand{literal: s}, err
+	case p.peek('-') || !p.end() && isDigit(p.s[p.i]):
+		start := p.i
+		for !p.end() && strings.IndexByte("+-.eE0123456789", p.s[p.i]) != -1 {
+			p.i++
+		}
+		f, err := strconv.ParseFloat(p.s[start:p.i], 64)
+		if err != nil {
+			p.i = start
+			return operand{}, p.syntaxError("number")
+		}
+		return operand{literal: f}, nil
+	}
+	start := p.i
+	switch p.parseIdent() {
+	case "true":
+		return operand{literal: true}, nil
+	case "false":
+		return operand{literal: false}, nil
+	case "null":
+		return operand{literal: nil}, nil
+	}
+	p.i = start
+	return operand{}, p.syntaxError("'@' or a literal")
+}
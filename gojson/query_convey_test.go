@@ -0,0 +1,125 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyQuery(t *testing.T) {
+	src := `{
+		"name": "Joe" ` + "`\"max-length\": 4`" + `,
+		"sister": {
+			"name": "Jessy",
+			"colors": ["red", "blue", "dark"] ` + "`\"editable\": false`" + `
+		} ` + "`\"editable\": false`" + `,
+		"friends": [
+			{"id": 0, "name": "Ann"},
+			{"id": 1, "name": "Jim", "first name": "James"},
+			{"id": 2, "name": "Jane"}
+		]
+	}`
+	root, _, err := ParseAsArrayOrSlice(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Get", t, func() {
+		Convey("Should follow keys and indexes", func() {
+			node, err := Get(root, "sister.colors[1]")
+			So(err, ShouldBeNil)
+			So(node.Value, ShouldEqual, "blue")
+
+			node, err = Get(root, `$.friends[-2]["first name"]`)
+			So(err, ShouldBeNil)
+			So(node.Value, ShouldEqual, "James")
+
+			node, err = Get(Node{Value: root}, "$")
+			So(err, ShouldBeNil)
+			So(node.Value, ShouldResemble, root)
+		})
+
+		Convey("Should report missing values and bad paths", func() {
+			_, err := Get(root, "sister.colors[3]")
+			So(err, ShouldEqual, ErrNotFound)
+			_, err = Get(root, "name.first")
+			So(err, ShouldEqual, ErrNotFound)
+			_, err = Get(root, "friends[*]")
+			So(err, ShouldNotBeNil)
+			_, err = Get(root, "sister..name")
+			So(err, ShouldNotBeNil)
+			_, err = Get(root, "friends[1")
+			So(err.Error(), ShouldEqual, `gojson: invalid path "friends[1": unexpected end of path at offset 9, expected ']'`)
+		})
+
+		Convey("Should read paths of matches back", func() {
+			odd := map[string]Node{"a:b": {Value: map[string]Node{"x,y": {Value: 1.0}}}, "a/b": {Value: 2.0}}
+			matches, err := Query(odd, "$..*")
+			So(err, ShouldBeNil)
+			So(len(matches), ShouldEqual, 3)
+			for _, m := range matches {
+				node, err := Get(odd, m.Path)
+				So(err, ShouldBeNil)
+				So(node, ShouldResemble, m.Node)
+			}
+		})
+	})
+
+	Convey("Query", t, func() {
+		paths := func(matches []Match) []string {
+			result := []string{}
+			for _, m := range matches {
+				result = append(result, m.Path)
+			}
+			return result
+		}
+
+		Convey("Should select children, slices and descendants", func() {
+			matches, err := Query(root, "$.friends[*].name")
+			So(err, ShouldBeNil)
+			So(paths(matches), ShouldResemble, []string{"friends[0].name", "friends[1].name", "friends[2].name"})
+			So(matches[1].Node.Value, ShouldEqual, "Jim")
+
+			matches, _ = Query(root, "sister.colors[1:]")
+			So(paths(matches), ShouldResemble, []string{"sister.colors[1]", "sister.colors[2]"})
+
+			matches, _ = Query(root, "$..name")
+			So(paths(matches), ShouldResemble, []string{"name", "friends[0].name",
+				"friends[1].name", "friends[2].name", "sister.name"})
+
+			matches, _ = Query(root, "$.sister.*")
+			So(len(matches), ShouldEqual, 2)
+		})
+
+		Convey("Should filter by values", func() {
+			matches, err := Query(root, "$.friends[?(@.id > 0)].name")
+			So(err, ShouldBeNil)
+			So(paths(matches), ShouldResemble, []string{"friends[1].name", "friends[2].name"})
+
+			matches, _ = Query(root, `$.friends[?(@["first name"] || @.name =~ '^A' && !(@.id != 0))]`)
+			So(paths(matches), ShouldResemble, []string{"friends[0]", "friends[1]"})
+
+			matches, _ = Query(root, "$.sister.colors[?(@ == 'dark')]")
+			So(paths(matches), ShouldResemble, []string{"sister.colors[2]"})
+		})
+
+		Convey("Should filter by tags", func() {
+			matches, err := Query(root, "$..[?(@#editable == false)]")
+			So(err, ShouldBeNil)
+			So(paths(matches), ShouldResemble, []string{"sister", "sister.colors"})
+
+			matches, _ = Query(root, `$[?(@#"max-length" < 10)]`)
+			So(paths(matches), ShouldResemble, []string{"name"})
+
+			matches, _ = Query(root, "$[?(@.colors#editable)].name")
+			So(paths(matches), ShouldResemble, []string{"sister.name"})
+		})
+
+		Convey("Should report invalid queries", func() {
+			for _, query := range []string{"$.friends[?(@.id >)]", "$.friends[?(@.id > 0]", "$[?(1)]",
+				"$[?(@.name =~ '(')]", "$..", "$[1:2"} {
+				_, err := Query(root, query)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
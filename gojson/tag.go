@@ -0,0 +1,322 @@
+package gojson
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TagOp is a comparison operator placed between a tag key and its value,
+// like in `"number": < 400`.
+type TagOp string
+
+const (
+	TagOpNone TagOp = ""
+	TagOpEq   TagOp = "=="
+	TagOpNe   TagOp = "!="
+	TagOpLt   TagOp = "<"
+	TagOpLe   TagOp = "<="
+	TagOpGt   TagOp = ">"
+	TagOpGe   TagOp = ">="
+)
+
+// TagEntry is a single `key: value` pair of a gojson tag. Value holds
+// string, float64, bool, nil, []interface{} or map[string]interface{}.
+type TagEntry struct {
+	Key   string
+	Op    TagOp
+	Value interface{}
+}
+
+// TagSet is a parsed gojson tag. Entries keep the order of the source tag.
+type TagSet []TagEntry
+
+// ParseTag parses the contents of a gojson tag. Keys may be quoted or bare
+// like in struct tags, entries may be separated by commas or whitespace:
+//
+//	"number": < 400, "list": ["red", "blue"]
+//	limit:"10" unique:true
+func ParseTag(tag string) (TagSet, error) {
+	p := tagParser{s: tag}
+	ts := TagSet{}
+	p.skipSpace()
+	for !p.end() {
+		entry, err := p.parseEntry()
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, entry)
+		p.skipSpace()
+		if !p.end() && p.s[p.pos] == ',' {
+			p.pos++
+			p.skipSpace()
+			if p.end() {
+				return nil, p.syntaxError("tag key")
+			}
+		}
+	}
+	return ts, nil
+}
+
+// Tags parses the tag of the node. Node without a tag has an empty TagSet.
+func (n Node) Tags() (TagSet, error) {
+	return ParseTag(n.Tag)
+}
+
+// Get returns the first entry with the key.
+func (ts TagSet) Get(key string) (TagEntry, bool) {
+	for _, entry := range ts {
+		if entry.Key == key {
+			return entry, true
+		}
+	}
+	return TagEntry{}, false
+}
+
+// String returns the canonical text of the tag set, the one gojson
+// serializer puts between backticks.
+func (ts TagSet) String() string {
+	entries := make([]string, len(ts))
+	for i, entry := range ts {
+		entries[i] = entry.String()
+	}
+	return strings.Join(entries, ", ")
+}
+
+// String returns the canonical text of the entry.
+func (e TagEntry) String() string {
+	s := quoteString(e.Key) + ": "
+	if e.Op != TagOpNone {
+		s += string(e.Op) + " "
+	}
+	return s + formatTagValue(e.Value)
+}
+
+func formatTagValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return quoteString(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatTagValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = quoteString(key) + ": " + formatTagValue(v[key])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return getValue(v)
+	}
+}
+
+type tagParser struct {
+	s   string
+	pos int
+}
+
+func (p *tagParser) end() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *tagParser) skipSpace() {
+	for !p.end() && isTagSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *tagParser) syntaxError(expected string) error {
+	return syntaxError([]byte(p.s), p.pos, expected)
+}
+
+func (p *tagParser) parseEntry() (TagEntry, error) {
+	entry := TagEntry{}
+	var err error
+	if p.s[p.pos] == '"' {
+		entry.Key, err = p.parseString()
+	} else {
+		entry.Key, err = p.parseIdent()
+	}
+	if err != nil {
+		return entry, err
+	}
+	p.skipSpace()
+	if p.end() || p.s[p.pos] != ':' {
+		return entry, p.syntaxError("':'")
+	}
+	p.pos++
+	p.skipSpace()
+	entry.Op = p.parseOp()
+	p.skipSpace()
+	entry.Value, err = p.parseValue()
+	return entry, err
+}
+
+func (p *tagParser) parseIdent() (string, error) {
+	start := p.pos
+	for !p.end() && isTagIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.syntaxError("tag key")
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *tagParser) parseOp() TagOp {
+	for _, op := range []TagOp{TagOpLe, TagOpGe, TagOpEq, TagOpNe, TagOpLt, TagOpGt} {
+		if strings.HasPrefix(p.s[p.pos:], string(op)) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return TagOpNone
+}
+
+func (p *tagParser) parseValue() (interface{}, error) {
+	if p.end() {
+		return nil, p.syntaxError("tag value")
+	}
+	switch c := p.s[p.pos]; {
+	case c == '"':
+		return p.parseString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseObject()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	}
+	word, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	p.pos -= len(word)
+	return nil, p.syntaxError("tag value")
+}
+
+func (p *tagParser) parseString() (string, error) {
+	s, end, err := scanString([]byte(p.s), p.pos)
+	if err != nil {
+		return "", err
+	}
+	p.pos = end + 1
+	return string(s), nil
+}
+
+func (p *tagParser) parseNumber() (float64, error) {
+	start := p.pos
+	for !p.end() && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) != -1 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return 0, syntaxError([]byte(p.s), start, "number")
+	}
+	return v, nil
+}
+
+func (p *tagParser) parseArray() ([]interface{}, error) {
+	result := []interface{}{}
+	p.pos++
+	p.skipSpace()
+	if !p.end() && p.s[p.pos] == ']' {
+		p.pos++
+		return result, nil
+	}
+	for {
+		p.skipSpace()
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+		p.skipSpace()
+		if p.end() {
+			return nil, p.syntaxError("',' or ']'")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return result, nil
+		default:
+			return nil, p.syntaxError("',' or ']'")
+		}
+	}
+}
+
+func (p *tagParser) parseObject() (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	p.pos++
+	p.skipSpace()
+	if !p.end() && p.s[p.pos] == '}' {
+		p.pos++
+		return result, nil
+	}
+	for {
+		p.skipSpace()
+		if p.end() || p.s[p.pos] != '"' {
+			return nil, p.syntaxError("string")
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.end() || p.s[p.pos] != ':' {
+			return nil, p.syntaxError("':'")
+		}
+		p.pos++
+		p.skipSpace()
+		result[key], err = p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.end() {
+			return nil, p.syntaxError("',' or '}'")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return result, nil
+		default:
+			return nil, p.syntaxError("',' or '}'")
+		}
+	}
+}
+
+func isTagSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isTagIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,68 @@
+package gojson
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyParseTag(t *testing.T) {
+	Convey("Parsing gojson tags", t, func() {
+		Convey("Should parse quoted keys, operators and literals", func() {
+			ts, err := ParseTag(`"number": < 400, "editable": false, "max-length": 4, "name": "Joe"`)
+			So(err, ShouldBeNil)
+			So(len(ts), ShouldEqual, 4)
+			So(ts[0], ShouldResemble, TagEntry{Key: "number", Op: TagOpLt, Value: 400.0})
+			So(ts[1], ShouldResemble, TagEntry{Key: "editable", Value: false})
+			So(ts[2].Value, ShouldEqual, 4)
+			So(ts[3].Value, ShouldEqual, "Joe")
+		})
+
+		Convey("Should parse arrays and every operator", func() {
+			ts, err := ParseTag(`"list": ["red", "blue", 1, null], "a": <= 1, "b": >= -2.5, "c": != "x", "d": == true, "e": > 0`)
+			So(err, ShouldBeNil)
+			So(ts[0].Value, ShouldResemble, []interface{}{"red", "blue", 1.0, nil})
+			So(ts[1].Op, ShouldEqual, TagOpLe)
+			So(ts[2].Op, ShouldEqual, TagOpGe)
+			So(ts[2].Value, ShouldEqual, -2.5)
+			So(ts[3].Op, ShouldEqual, TagOpNe)
+			So(ts[4].Op, ShouldEqual, TagOpEq)
+			So(ts[5].Op, ShouldEqual, TagOpGt)
+		})
+
+		Convey("Should parse struct tag style entries", func() {
+			ts, err := ParseTag(`limit:"10" unique:true`)
+			So(err, ShouldBeNil)
+			So(ts, ShouldResemble, TagSet{
+				TagEntry{Key: "limit", Value: "10"},
+				TagEntry{Key: "unique", Value: true},
+			})
+			entry, ok := ts.Get("unique")
+			So(ok, ShouldBeTrue)
+			So(entry.Value, ShouldEqual, true)
+			_, ok = ts.Get("missing")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Should return error on broken tags", func() {
+			for _, tag := range []string{`"a"`, `"a": `, `"a": [1, 2`, `"a": nope`, `"a": 1,`, `: 1`} {
+				_, err := ParseTag(tag)
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("Should serialize to canonical text", func() {
+			ts, _ := ParseTag(`"number":<400,list:["red","blue"]  "o": {"b": 1, "a": "x"}`)
+			So(ts.String(), ShouldEqual, `"number": < 400, "list": ["red", "blue"], "o": {"a": "x", "b": 1}`)
+			again, err := ParseTag(ts.String())
+			So(err, ShouldBeNil)
+			So(again, ShouldResemble, ts)
+		})
+
+		Convey("Should be available from parsed nodes", func() {
+			m, _, _ := ParseAsArrayOrSlice(`{"name": "Joe" ` + "`\"max-length\": 4`" + `}`)
+			ts, err := m["name"].Tags()
+			So(err, ShouldBeNil)
+			So(ts, ShouldResemble, TagSet{TagEntry{Key: "max-length", Value: 4.0}})
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package gojson
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayout is the layout of time.Time values unless another one
+// is set for the Encoder or the Decoder, for a struct field or by the
+// "format" key of the tag.
+const DefaultTimeLayout = time.RFC3339Nano
+
+// legacyTimeLayouts are the layouts time.Time values used to be written
+// with. They are still accepted on parse.
+var legacyTimeLayouts = []string{"2006-01-02 15:04:05", "15:04:05"}
+
+// formatLayouts are the time layouts of the "format" tag key values, like
+// `"format": "date-time"`.
+var formatLayouts = map[string]string{
+	"date-time": time.RFC3339Nano,
+	"date":      "2006-01-02",
+	"time":      "15:04:05",
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// tagFormat returns the value of the "format" key of a gojson tag.
+func tagFormat(tag string) string {
+	if !strings.Contains(tag, `"format"`) {
+		return ""
+	}
+	tags, err := ParseTag(tag)
+	if err != nil {
+		return ""
+	}
+	entry, _ := tags.Get("format")
+	format, _ := entry.Value.(string)
+	return format
+}
+
+// timeLayout returns the first of the layouts which isn't empty.
+func timeLayout(layouts ...string) string {
+	for _, layout := range layouts {
+		if layout != "" {
+			return layout
+		}
+	}
+	return DefaultTimeLayout
+}
+
+// parseTime parses s with layout, falling back to DefaultTimeLayout and
+// the legacy layouts.
+func parseTime(s, layout string) (time.Time, error) {
+	t, err := time.Parse(layout, s)
+	if err == nil {
+		return t, nil
+	}
+	for _, fallback := range append([]string{DefaultTimeLayout}, legacyTimeLayouts...) {
+		if t, fallbackErr := time.Parse(fallback, s); fallbackErr == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// Time returns the value of the node as time.Time. String values are
+// parsed with the layout of the "format" key of the node tag, if any, or
+// DefaultTimeLayout.
+func (n Node) Time() (time.Time, error) {
+	switch v := n.Value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return parseTime(v, timeLayout(formatLayouts[tagFormat(n.Tag)]))
+	}
+	return time.Time{}, &UnmarshalTypeError{GoType: timeType, GoJSONType: valueKind(n.Value)}
+}
+
+// setTime stores the node value into f of type time.Time or
+// time.Duration. It reports whether f is of one of those types.
+func (d *decodeState) setTime(f reflect.Value, node Node, path decodePath) (bool, error) {
+	switch f.Type() {
+	case timeType:
+		s, ok := node.Value.(string)
+		if !ok {
+			return true, &UnmarshalTypeError{Path: path.key, GoType: timeType, GoJSONType: valueKind(node.Value)}
+		}
+		t, err := parseTime(s, timeLayout(d.fieldLayout, formatLayouts[tagFormat(node.Tag)], d.timeLayout))
+		if err != nil {
+			return true, err
+		}
+		f.Set(reflect.ValueOf(t))
+		return true, nil
+	case durationType:
+		if s, ok := node.Value.(string); ok {
+			duration, err := time.ParseDuration(s)
+			if err != nil {
+				return true, err
+			}
+			f.SetInt(int64(duration))
+			return true, nil
+		}
+		if !setNumber(f, node.Value) {
+			return true, &UnmarshalTypeError{Path: path.key, GoType: durationType, GoJSONType: valueKind(node.Value)}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// formatTimes replaces time.Time values inside of node with their text in
+// the layout.
+func formatTimes(node Node, layout string) Node {
+	switch v := node.Value.(type) {
+	case time.Time:
+		node.Value = v.Format(layout)
+	case []Node:
+		items := make([]Node, len(v))
+		for i, item := range v {
+			items[i] = formatTimes(item, layout)
+		}
+		node.Value = items
+	case map[string]Node:
+		m := make(map[string]Node, len(v))
+		for key, item := range v {
+			m[key] = formatTimes(item, layout)
+		}
+		node.Value = m
+	}
+	return node
+}
+
+// structTagLayout returns the time layout of the gojson struct tag key,
+// like `gojson:"layout=2006-01-02"`.
+func structTagLayout(tag reflect.StructTag) string {
+	for _, option := range strings.Split(tag.Get("gojson"), ";") {
+		if strings.HasPrefix(option, "layout=") {
+			return strings.TrimPrefix(option, "layout=")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package gojson
+
+import (
+	"bytes"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+	"time"
+)
+
+type timeEvent struct {
+	Created  time.Time     `json:"created"`
+	Day      time.Time     `json:"day" gojson:"layout=2006-01-02" limit:"10"`
+	Days     []time.Time   `json:"days" gojson:"layout=02.01.2006"`
+	Finished *time.Time    `json:"finished"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+func TestConveyTime(t *testing.T) {
+	kyiv := time.FixedZone("EET", 2*60*60)
+	created := time.Date(2017, 3, 4, 15, 16, 17, 123456789, kyiv)
+	day := time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)
+	event := timeEvent{
+		Created: created,
+		Day:     day,
+		Days:    []time.Time{day},
+		Timeout: 90 * time.Second,
+	}
+
+	Convey("Time values", t, func() {
+		Convey("Should be written with RFC 3339 by default and field layouts", func() {
+			re, err := SerializeStruct(event, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldEqual, `{"created":"2017-03-04T15:16:17.123456789+02:00","day":"2017-03-05"`+
+				"`limit:\"10\"`"+`,"days":["05.03.2017"],"finished":null,"timeout":"1m30s"}`)
+		})
+
+		Convey("Should round trip through structs keeping zone and precision", func() {
+			finished := created.Add(time.Hour)
+			withFinished := event
+			withFinished.Finished = &finished
+			data, err := Marshal(withFinished)
+			So(err, ShouldBeNil)
+			back := timeEvent{}
+			So(Unmarshal(data, &back), ShouldBeNil)
+			So(back.Created.Equal(created), ShouldBeTrue)
+			So(back.Created.Format(time.RFC3339Nano), ShouldEqual, created.Format(time.RFC3339Nano))
+			So(back.Day, ShouldResemble, day)
+			So(back.Days, ShouldResemble, []time.Time{day})
+			So(back.Finished.Equal(finished), ShouldBeTrue)
+			So(back.Timeout, ShouldEqual, 90*time.Second)
+		})
+
+		Convey("Should follow the format hint of the tag", func() {
+			m := map[string]Node{
+				"day":     {Value: day, Tag: `"format": "date"`},
+				"created": {Value: created, Tag: `"format": "date-time", "editable": false`},
+			}
+			re, err := Serialize(m, true)
+			So(err, ShouldBeNil)
+			So(re, ShouldContainSubstring, `"day":"2017-03-05"`)
+
+			parsed, _, err := ParseAsArrayOrSlice(re)
+			So(err, ShouldBeNil)
+			tm, err := parsed["day"].Time()
+			So(err, ShouldBeNil)
+			So(tm, ShouldResemble, day)
+			So(len(Validate(parsed)), ShouldEqual, 0)
+
+			var v struct {
+				Finished time.Time `json:"finished"`
+			}
+			So(ParseToStruct(&v, `{"finished": "05/03/17" `+"`\"format\": \"date\"`"+`}`), ShouldNotBeNil)
+			So(ParseToStruct(&v, `{"finished": "2017-03-05" `+"`\"format\": \"date\"`"+`}`), ShouldBeNil)
+			So(v.Finished, ShouldResemble, day)
+		})
+
+		Convey("Should accept the legacy layouts", func() {
+			var v timeEvent
+			So(ParseToStruct(&v, `{"created": "2017-03-04 15:16:17", "timeout": 1000}`), ShouldBeNil)
+			So(v.Created, ShouldResemble, time.Date(2017, 3, 4, 15, 16, 17, 0, time.UTC))
+			So(v.Timeout, ShouldEqual, time.Microsecond)
+		})
+
+		Convey("Encoder and Decoder layouts should be configurable", func() {
+			var b bytes.Buffer
+			enc := NewEncoder(&b)
+			enc.SetTimeLayout(time.Kitchen)
+			So(enc.Encode(map[string]Node{"at": {Value: created}}), ShouldBeNil)
+			So(b.String(), ShouldEqual, `{"at":"3:16PM"}`+"\n")
+
+			dec := NewDecoder(strings.NewReader(`{"created": "3:16PM"}`))
+			dec.SetTimeLayout(time.Kitchen)
+			var v timeEvent
+			So(dec.Decode(&v), ShouldBeNil)
+			So(v.Created.Hour(), ShouldEqual, 15)
+		})
+
+		Convey("Format tag should be validated", func() {
+			errs := Validate(map[string]Node{
+				"a": {Value: "yesterday", Tag: `"format": "date-time"`},
+				"b": {Value: "1h", Tag: `"format": "duration"`},
+				"c": {Value: "x", Tag: `"format": "color"`},
+			})
+			So(len(errs), ShouldEqual, 2)
+			So(errs[0].Message, ShouldEqual, `value "yesterday" is not a date-time`)
+			So(errs[1].Message, ShouldEqual, `unknown format "color"`)
+		})
+	})
+}
@@ -0,0 +1,442 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError describes a node which violates a constraint of its tag.
+// Warning is set for problems which Validator was asked to only warn about.
+type ValidationError struct {
+	Path    string
+	Tag     string
+	Message string
+	Warning bool
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// TagRule checks a node against a custom tag. arg is the parsed value of
+// the tag entry and path is the path to the node.
+type TagRule func(node Node, arg interface{}, path string) error
+
+// UnknownTagPolicy tells Validator what to do with tag keys which neither
+// built-in nor registered rules know about.
+type UnknownTagPolicy int
+
+const (
+	IgnoreUnknownTags UnknownTagPolicy = iota
+	WarnUnknownTags
+	RejectUnknownTags
+)
+
+var (
+	tagRulesMu sync.RWMutex
+	tagRules   = map[string]TagRule{}
+)
+
+// RegisterTagRule registers a rule for the custom tag key globally. It is
+// used by Validate and by every Validator created afterwards. Built-in
+// rules can't be overridden.
+func RegisterTagRule(name string, fn TagRule) {
+	tagRulesMu.Lock()
+	defer tagRulesMu.Unlock()
+	tagRules[name] = fn
+}
+
+// Validator validates Node trees with its own set of custom tag rules.
+type Validator struct {
+	UnknownTags UnknownTagPolicy
+	rules       map[string]TagRule
+}
+
+// NewValidator returns a validator which knows the built-in rules and
+// the rules registered globally so far.
+func NewValidator() *Validator {
+	tagRulesMu.RLock()
+	defer tagRulesMu.RUnlock()
+	rules := make(map[string]TagRule, len(tagRules))
+	for name, fn := range tagRules {
+		rules[name] = fn
+	}
+	return &Validator{rules: rules}
+}
+
+// RegisterTagRule registers a rule for the custom tag key in this
+// validator only.
+func (v *Validator) RegisterTagRule(name string, fn TagRule) {
+	if v.rules == nil {
+		v.rules = map[string]TagRule{}
+	}
+	v.rules[name] = fn
+}
+
+// Validate works like the package level Validate using rules and the
+// unknown tag policy of the validator.
+func (v *Validator) Validate(root interface{}) []ValidationError {
+	w := validator{rules: v.rules, unknown: v.UnknownTags}
+	w.validateNode(Node{Value: root}, "")
+	return w.errs
+}
+
+// tagRule checks a node against a single entry of its tag.
+type tagRule func(node Node, entry TagEntry) error
+
+var builtinTagRules = map[string]tagRule{
+	"number":     checkNumber,
+	"max-length": checkMaxLength,
+	"min-length": checkMinLength,
+	"list":       checkList,
+	"enum":       checkList,
+	"required":   checkRequired,
+	"unique":     checkUnique,
+	"pattern":    checkPattern,
+	"format":     checkFormat,
+}
+
+// Validate checks every node of map[string]Node or []Node against the
+// constraints carried by its tag and returns all violations found. Supported
+// tags are:
+//
+//	"number": < 400         numeric comparison, any of <, <=, >, >=, ==, !=
+//	"max-length": 4         maximum length of a string or an array
+//	"min-length": 1         minimum length of a string or an array
+//	"list": ["red", "blue"] value should be one of the listed, "enum" is an alias
+//	"required": true        value should not be null or an empty string
+//	"unique": true          value should be unique among array elements
+//	"pattern": "^[a-z]+$"   string should match the regular expression
+//	"format": "date-time"   string should be a time of the format: "date-time"
+//	                        (RFC 3339), "date", "time" or "duration"
+//
+// Tags with other keys are checked by rules added with RegisterTagRule
+// or ignored.
+func Validate(root interface{}) []ValidationError {
+	return NewValidator().Validate(root)
+}
+
+type validator struct {
+	rules   map[string]TagRule
+	unknown UnknownTagPolicy
+	errs    []ValidationError
+}
+
+func (v *validator) addError(path, tag string, err error) {
+	v.errs = append(v.errs, ValidationError{
+		Path:    path,
+		Tag:     tag,
+		Message: err.Error(),
+	})
+}
+
+func (v *validator) validateNode(node Node, path string) {
+	ts, err := node.Tags()
+	if err != nil {
+		v.addError(path, "", fmt.Errorf("invalid tag: %s", err))
+	}
+	for _, entry := range ts {
+		if rule, ok := builtinTagRules[entry.Key]; ok {
+			if err := rule(node, entry); err != nil {
+				v.addError(path, entry.Key, err)
+			}
+		} else if rule, ok := v.rules[entry.Key]; ok {
+			if err := rule(node, entry.Value, path); err != nil {
+				v.addError(path, entry.Key, err)
+			}
+		} else if v.unknown != IgnoreUnknownTags {
+			v.addError(path, entry.Key, fmt.Errorf("unknown tag %s", quoteString(entry.Key)))
+			if v.unknown == WarnUnknownTags {
+				v.errs[len(v.errs)-1].Warning = true
+			}
+		}
+	}
+	switch value := node.Value.(type) {
+	case map[string]Node, *OrderedMap:
+		nodes, _ := objectNodes(value)
+		for _, key := range objectKeys(value) {
+			v.validateNode(nodes[key], joinPath(path, key))
+		}
+	case []Node:
+		for i, item := range value {
+			v.validateNode(item, indexPath(path, i))
+		}
+		v.validateUniqueItems(value, path)
+	}
+}
+
+// validateUniqueItems checks "unique" tags of array elements and of the
+// fields of array elements which are objects.
+func (v *validator) validateUniqueItems(items []Node, path string) {
+	seen := []Node{}
+	fields := map[string][]Node{}
+	for i, item := range items {
+		if hasUniqueTag(item) {
+			if containsValue(seen, item.Value) {
+				v.addError(indexPath(path, i), "unique", errors.New("value is not unique"))
+			}
+			seen = append(seen, item)
+		}
+		m, ok := objectNodes(item.Value)
+		if !ok {
+			continue
+		}
+		for _, key := range objectKeys(item.Value) {
+			field := m[key]
+			if !hasUniqueTag(field) {
+				continue
+			}
+			if containsValue(fields[key], field.Value) {
+				v.addError(joinPath(indexPath(path, i), key), "unique", errors.New("value is not unique"))
+			}
+			fields[key] = append(fields[key], field)
+		}
+	}
+}
+
+func hasUniqueTag(node Node) bool {
+	ts, _ := node.Tags()
+	entry, ok := ts.Get("unique")
+	return ok && entry.Value == true
+}
+
+func checkNumber(node Node, entry TagEntry) error {
+	value, ok := toFloat(node.Value)
+	if !ok {
+		return errors.New("value is not a number")
+	}
+	if entry.Value == true {
+		return nil
+	}
+	limit, ok := entry.Value.(float64)
+	if !ok {
+		return errors.New("tag value should be a number")
+	}
+	if !compareFloat(value, entry.Op, limit) {
+		op := entry.Op
+		if op == TagOpNone {
+			op = TagOpEq
+		}
+		return fmt.Errorf("value %v is not %s %v", node.Value, op, formatTagValue(limit))
+	}
+	return nil
+}
+
+func compareFloat(a float64, op TagOp, b float64) bool {
+	switch op {
+	case TagOpNe:
+		return a != b
+	case TagOpLt:
+		return a < b
+	case TagOpLe:
+		return a <= b
+	case TagOpGt:
+		return a > b
+	case TagOpGe:
+		return a >= b
+	}
+	return a == b
+}
+
+func checkMaxLength(node Node, entry TagEntry) error {
+	length, limit, err := lengthAndLimit(node, entry)
+	if err != nil {
+		return err
+	}
+	if length > limit {
+		return fmt.Errorf("length %d exceeds max-length %d", length, limit)
+	}
+	return nil
+}
+
+func checkMinLength(node Node, entry TagEntry) error {
+	length, limit, err := lengthAndLimit(node, entry)
+	if err != nil {
+		return err
+	}
+	if length < limit {
+		return fmt.Errorf("length %d is less than min-length %d", length, limit)
+	}
+	return nil
+}
+
+func lengthAndLimit(node Node, entry TagEntry) (int, int, error) {
+	limit, ok := entry.Value.(float64)
+	if !ok {
+		return 0, 0, errors.New("tag value should be a number")
+	}
+	switch value := node.Value.(type) {
+	case string:
+		return utf8.RuneCountInString(value), int(limit), nil
+	case []Node:
+		return len(value), int(limit), nil
+	}
+	return 0, 0, errors.New("value is neither a string nor an array")
+}
+
+func checkList(node Node, entry TagEntry) error {
+	list, ok := entry.Value.([]interface{})
+	if !ok {
+		return errors.New("tag value should be an array")
+	}
+	for _, item := range list {
+		if valuesEqual(node.Value, item) {
+			return nil
+		}
+	}
+	if items, ok := node.Value.([]Node); ok {
+		for i, item := range items {
+			if err := checkList(item, entry); err != nil {
+				return fmt.Errorf("element %d: %s", i, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("value is not one of %s", formatTagValue(list))
+}
+
+func checkFormat(node Node, entry TagEntry) error {
+	format, ok := entry.Value.(string)
+	if !ok {
+		return errors.New("tag value should be a string")
+	}
+	s, ok := node.Value.(string)
+	if !ok {
+		return nil
+	}
+	var err error
+	if format == "duration" {
+		_, err = time.ParseDuration(s)
+	} else if layout, ok := formatLayouts[format]; ok {
+		_, err = time.Parse(layout, s)
+	} else {
+		return fmt.Errorf("unknown format %s", quoteString(format))
+	}
+	if err != nil {
+		return fmt.Errorf("value %s is not a %s", quoteString(s), format)
+	}
+	return nil
+}
+
+func checkRequired(node Node, entry TagEntry) error {
+	if entry.Value != true {
+		return nil
+	}
+	if node.Value == nil || node.Value == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+func checkUnique(node Node, entry TagEntry) error {
+	items, ok := node.Value.([]Node)
+	if !ok || entry.Value != true {
+		return nil
+	}
+	for i := range items {
+		if containsValue(items[:i], items[i].Value) {
+			return fmt.Errorf("element %d is not unique", i)
+		}
+	}
+	return nil
+}
+
+func checkPattern(node Node, entry TagEntry) error {
+	pattern, ok := entry.Value.(string)
+	if !ok {
+		return errors.New("tag value should be a string")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %s", err)
+	}
+	value, ok := node.Value.(string)
+	if !ok {
+		return errors.New("value is not a string")
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("value does not match pattern %s", formatTagValue(pattern))
+	}
+	return nil
+}
+
+func containsValue(nodes []Node, value interface{}) bool {
+	for _, node := range nodes {
+		if valuesEqual(node.Value, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual compares node values ignoring tags. Numbers of different
+// types are equal if they hold the same number.
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch a := a.(type) {
+	case map[string]Node, *OrderedMap:
+		am, _ := objectNodes(a)
+		bm, ok := objectNodes(b)
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for key, node := range am {
+			other, ok := bm[key]
+			if !ok || !valuesEqual(node.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	case []Node:
+		b, ok := b.([]Node)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !valuesEqual(a[i].Value, b[i].Value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !valuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	if n, ok := v.(Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
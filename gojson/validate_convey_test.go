@@ -0,0 +1,126 @@
+package gojson
+
+import (
+	"errors"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func TestConveyValidate(t *testing.T) {
+	Convey("Validating nodes against their tags", t, func() {
+		Convey("Valid document should have no errors", func() {
+			m, _, err := ParseAsArrayOrSlice(`{
+				"name": "Joe" ` + "`\"max-length\": 4, \"required\": true`" + `,
+				"age": 40 ` + "`\"number\": < 400`" + `,
+				"colors": ["red", "blue"] ` + "`\"list\": [\"red\", \"blue\", \"green\"], \"unique\": true`" + `,
+				"code": "ab" ` + "`\"pattern\": \"^[a-z]+$\", \"custom\": 1`" + `
+			}`)
+			So(err, ShouldBeNil)
+			So(Validate(m), ShouldBeEmpty)
+		})
+
+		Convey("Should report every violation with its path", func() {
+			m := map[string]Node{
+				"name": Node{Value: "Jonathan", Tag: `"max-length": 4`},
+				"nick": Node{Value: "", Tag: `"required": true, "min-length": 1`},
+				"sister": Node{Value: map[string]Node{
+					"_id":    Node{Value: 500.5, Tag: `"number": < 400`},
+					"colors": Node{Value: []Node{Node{Value: "red"}, Node{Value: "pink"}}, Tag: `"list": ["red", "blue"]`},
+					"code":   Node{Value: "AB", Tag: `"pattern": "^[a-z]+$"`},
+				}},
+			}
+			errs := Validate(m)
+			So(errs, ShouldResemble, []ValidationError{
+				ValidationError{Path: "name", Tag: "max-length", Message: "length 8 exceeds max-length 4"},
+				ValidationError{Path: "nick", Tag: "required", Message: "value is required"},
+				ValidationError{Path: "nick", Tag: "min-length", Message: "length 0 is less than min-length 1"},
+				ValidationError{Path: "sister._id", Tag: "number", Message: "value 500.5 is not < 400"},
+				ValidationError{Path: "sister.code", Tag: "pattern", Message: `value does not match pattern "^[a-z]+$"`},
+				ValidationError{Path: "sister.colors", Tag: "list", Message: `element 1: value is not one of ["red", "blue"]`},
+			})
+			So(errs[3].Error(), ShouldEqual, "sister._id: value 500.5 is not < 400")
+		})
+
+		Convey("Should check uniqueness within arrays", func() {
+			_, arr, err := ParseAsArrayOrSlice(`[
+				{"name": "nickname", "id": 0 ` + "`unique:true`" + `},
+				{"name": "nickname2", "id": 1 ` + "`unique:true`" + `},
+				{"name": "nickname3", "id": 1 ` + "`unique:true`" + `}
+			]`)
+			So(err, ShouldBeNil)
+			errs := Validate(arr)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, "[2].id")
+			So(errs[0].Tag, ShouldEqual, "unique")
+
+			errs = Validate([]Node{Node{
+				Value: []Node{Node{Value: "a"}, Node{Value: "a"}},
+				Tag:   `"unique": true`,
+			}})
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, "[0]")
+		})
+
+		Convey("Should report broken tags", func() {
+			errs := Validate(map[string]Node{"a b": Node{Value: 1, Tag: `"number": <`}})
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Path, ShouldEqual, `["a b"]`)
+		})
+	})
+}
+
+func TestConveyCustomTagRules(t *testing.T) {
+	Convey("Validating with custom tag rules", t, func() {
+		m := map[string]Node{
+			"price": Node{Value: "10 USD", Tag: `"currency": "EUR"`},
+			"sku":   Node{Value: "AB-1", Tag: `"sku-format": true, "color": "red"`},
+		}
+		currency := func(node Node, arg interface{}, path string) error {
+			value, _ := node.Value.(string)
+			if len(value) < 3 || value[len(value)-3:] != arg {
+				return errors.New("wrong currency at " + path)
+			}
+			return nil
+		}
+
+		Convey("Should use rules registered in the validator", func() {
+			v := NewValidator()
+			v.RegisterTagRule("currency", currency)
+			v.RegisterTagRule("sku-format", func(node Node, arg interface{}, path string) error {
+				return nil
+			})
+			errs := v.Validate(m)
+			So(errs, ShouldResemble, []ValidationError{
+				ValidationError{Path: "price", Tag: "currency", Message: "wrong currency at price"},
+			})
+			So(Validate(m), ShouldBeEmpty)
+		})
+
+		Convey("Should use rules registered globally", func() {
+			RegisterTagRule("test-currency", currency)
+			defer func() {
+				tagRulesMu.Lock()
+				delete(tagRules, "test-currency")
+				tagRulesMu.Unlock()
+			}()
+			errs := Validate(map[string]Node{"price": Node{Value: "10 USD", Tag: `"test-currency": "EUR"`}})
+			So(len(errs), ShouldEqual, 1)
+		})
+
+		Convey("Should report unknown tags according to the policy", func() {
+			v := &Validator{UnknownTags: WarnUnknownTags}
+			v.RegisterTagRule("currency", currency)
+			errs := v.Validate(m)
+			So(len(errs), ShouldEqual, 3)
+			So(errs[1], ShouldResemble, ValidationError{
+				Path: "sku", Tag: "sku-format", Message: `unknown tag "sku-format"`, Warning: true,
+			})
+
+			v.UnknownTags = RejectUnknownTags
+			errs = v.Validate(m)
+			So(len(errs), ShouldEqual, 3)
+			So(errs[2].Tag, ShouldEqual, "color")
+			So(errs[2].Warning, ShouldBeFalse)
+		})
+	})
+}